@@ -3,8 +3,11 @@ package main
 import (
 	"log"
 	"os"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
 	"project-backend/internal/database"
 	"project-backend/internal/handlers"
+	"project-backend/internal/middleware"
 	"project-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -17,20 +20,52 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// Fail fast on malformed code patterns, grading scales, auth and mail settings
+	if err := handlers.ValidateCodePatterns(); err != nil {
+		log.Fatal(err)
+	}
+	if err := handlers.ValidateGradingScale(); err != nil {
+		log.Fatal(err)
+	}
+	if err := handlers.ValidateAuthConfig(); err != nil {
+		log.Fatal(err)
+	}
+	if err := handlers.InitMailer(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Connect to database
 	database.Connect()
 
 	// Auto migrate models
-	database.DB.AutoMigrate(&models.Student{})
+	database.Migrate()
+
+	// Only administrators can create accounts, so the first one comes from the environment
+	if err := handlers.BootstrapAdmin(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Rate limits need the database for the Postgres store
+	limits, err := middleware.LoadRateLimits()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize Gin router
 	r := gin.Default()
 
+	// Only trust X-Forwarded-For from the proxies in TRUSTED_PROXIES, so
+	// clients cannot pick their own IP for rate limiting and the audit log
+	if err := r.SetTrustedProxies(config.List("TRUSTED_PROXIES")); err != nil {
+		log.Fatal(err)
+	}
+
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Device-Key, X-Device-ID, X-Device-Timestamp, X-Device-Signature, X-Device-Nonce, X-Request-ID, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -40,17 +75,199 @@ func main() {
 		c.Next()
 	})
 
+	// Request IDs and client IPs for the audit log
+	r.Use(middleware.RequestContext())
+
 	// API routes
 	api := r.Group("/api/v1")
 	{
+		// Terminals are limited per device, so the limit runs once DeviceAuth
+		// has identified it; everything else is limited up front
+		limited := api.Group("", limits.For("default"))
+
+		// Auth routes
+		limited.POST("/auth/login", limits.For("auth"), handlers.Login)
+		limited.POST("/auth/accept-invite", limits.For("auth"), handlers.AcceptInvite)
+		limited.POST("/auth/forgot-password", limits.For("auth"), handlers.ForgotPassword)
+		limited.POST("/auth/reset-password", limits.For("auth"), handlers.ResetPassword)
+		limited.POST("/auth/2fa/verify", limits.For("auth"), handlers.VerifyLoginTwoFactor)
+
+		// Self-service routes for the logged-in user
+		me := limited.Group("/me", middleware.UserAuth())
+		{
+			me.GET("", handlers.GetMe)
+			me.PUT("/password", handlers.ChangeMyPassword)
+			me.GET("/student", handlers.GetMyStudent)
+			me.PATCH("/student", handlers.UpdateMyStudent)
+			me.GET("/employee", handlers.GetMyEmployee)
+			me.GET("/attendance", handlers.GetMyAttendance)
+			me.GET("/leave-balance", handlers.GetMyLeaveBalance)
+			me.POST("/2fa/disable", handlers.DisableTwoFactor)
+			me.POST("/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+		}
+
+		// 2FA enrollment is also open to users who must enroll before logging in
+		enroll := limited.Group("/me/2fa", middleware.UserAuth(auth.ScopeMFASetup))
+		{
+			enroll.POST("/setup", handlers.SetupTwoFactor)
+			enroll.POST("/enable", handlers.EnableTwoFactor)
+		}
+
+		// User routes (administrators only)
+		users := limited.Group("/users", middleware.UserAuth(), middleware.RequireRole(models.UserRoleAdmin), middleware.Idempotency())
+		{
+			users.GET("", handlers.GetUsers)
+			users.POST("", handlers.CreateUser)
+			users.GET("/:id", handlers.GetUser)
+			users.PUT("/:id", handlers.UpdateUser)
+			users.POST("/:id/invite", handlers.ResendInvite)
+			users.DELETE("/:id/2fa", handlers.ResetUserTwoFactor)
+		}
+
+		// Routes below need an access token, and most of them a staff role
+		admin := limited.Group("", middleware.UserAuth(), middleware.RequireRole(models.UserRoleAdmin), middleware.Idempotency())
+		staff := limited.Group("", middleware.UserAuth(), middleware.RequireRole(models.UserRoleAdmin, models.UserRoleHR), middleware.Idempotency())
+		signedIn := limited.Group("", middleware.UserAuth(), middleware.Idempotency())
+
 		// Student routes
-		api.GET("/students", handlers.GetStudents)
-		api.POST("/students", handlers.CreateStudent)
-		api.GET("/students/:id", handlers.GetStudent)
-		api.PUT("/students/:id", handlers.UpdateStudent)
-		api.DELETE("/students/:id", handlers.DeleteStudent)
-		api.GET("/students/major", handlers.GetStudentsByMajor)
-		api.GET("/students/status", handlers.GetStudentsByStatus)
+		admin.GET("/students", handlers.GetStudents)
+		admin.POST("/students", handlers.CreateStudent)
+		admin.POST("/students/import", handlers.ImportStudents)
+		admin.GET("/students/export", handlers.ExportStudents)
+		admin.GET("/students/:id", handlers.GetStudent)
+		admin.PUT("/students/:id", handlers.UpdateStudent)
+		admin.DELETE("/students/:id", handlers.DeleteStudent)
+		admin.GET("/students/major", handlers.GetStudentsByMajor)
+		admin.GET("/students/status", handlers.GetStudentsByStatus)
+		admin.POST("/students/rollover", handlers.RunStudentRollover)
+		admin.POST("/students/:id/suspend", handlers.SuspendStudent)
+		admin.POST("/students/:id/reinstate", handlers.ReinstateStudent)
+		admin.POST("/students/:id/graduate", handlers.GraduateStudent)
+		admin.POST("/students/:id/withdraw", handlers.WithdrawStudent)
+		admin.GET("/students/:id/status-history", handlers.GetStudentStatusHistory)
+
+		// Employee routes
+		staff.GET("/employees", handlers.GetEmployees)
+		staff.POST("/employees", handlers.CreateEmployee)
+		staff.POST("/employees/import", handlers.ImportEmployees)
+		staff.GET("/employees/export", handlers.ExportEmployees)
+		staff.GET("/employees/status", handlers.GetEmployeesByStatus)
+		staff.GET("/employees/department/:departmentId", handlers.GetEmployeesByDepartment)
+		staff.GET("/employees/:id", handlers.GetEmployee)
+		staff.PUT("/employees/:id", handlers.UpdateEmployee)
+		staff.DELETE("/employees/:id", handlers.DeleteEmployee)
+		staff.GET("/employees/:id/managed-departments", handlers.GetManagedDepartments)
+		staff.POST("/employees/:id/suspend", handlers.SuspendEmployee)
+		staff.POST("/employees/:id/reinstate", handlers.ReinstateEmployee)
+		staff.POST("/employees/:id/terminate", handlers.TerminateEmployee)
+		staff.POST("/employees/:id/rehire", handlers.RehireEmployee)
+		staff.GET("/employees/:id/status-history", handlers.GetEmployeeStatusHistory)
+
+		// Department routes
+		staff.GET("/departments", handlers.GetDepartments)
+		staff.POST("/departments", handlers.CreateDepartment)
+		staff.GET("/departments/tree", handlers.GetDepartmentTree)
+		staff.GET("/departments/:id", handlers.GetDepartment)
+		staff.PUT("/departments/:id", handlers.UpdateDepartment)
+		staff.DELETE("/departments/:id", handlers.DeleteDepartment)
+		staff.POST("/departments/:id/restore", handlers.RestoreDepartment)
+		staff.GET("/departments/:id/descendants", handlers.GetDepartmentDescendants)
+		staff.PUT("/departments/:id/manager", handlers.SetDepartmentManager)
+		staff.GET("/departments/:id/manager-history", handlers.GetDepartmentManagerHistory)
+
+		// Attendance routes (terminals authenticate as registered devices).
+		// Guessing device keys is throttled per IP before DeviceAuth runs.
+		terminal := api.Group("/attendance", limits.ForIP("auth"), middleware.DeviceAuth(), limits.For("default"), middleware.Idempotency())
+		{
+			terminal.POST("/check-in", handlers.CheckIn)
+			terminal.POST("/check-out", handlers.CheckOut)
+			terminal.POST("/face-match", limits.For("face_match"), handlers.FaceMatch)
+		}
+		signedIn.POST("/attendance/mobile/check-in", handlers.MobileCheckIn)
+		signedIn.POST("/attendance/mobile/check-out", handlers.MobileCheckOut)
+		staff.GET("/attendance", handlers.GetAttendanceRecords)
+		staff.GET("/attendance/:id/history", handlers.GetAttendanceHistory)
+		signedIn.GET("/attendance/corrections", handlers.GetAttendanceCorrections)
+		signedIn.POST("/attendance/corrections", handlers.CreateAttendanceCorrection)
+		signedIn.GET("/attendance/corrections/:id", handlers.GetAttendanceCorrection)
+		signedIn.POST("/attendance/corrections/:id/approve", handlers.ApproveAttendanceCorrection)
+		signedIn.POST("/attendance/corrections/:id/reject", handlers.RejectAttendanceCorrection)
+
+		// Geofence routes
+		staff.GET("/departments/:id/geofences", handlers.GetDepartmentGeofences)
+		staff.POST("/departments/:id/geofences", handlers.CreateDepartmentGeofence)
+		staff.PUT("/geofences/:id", handlers.UpdateGeofence)
+		staff.DELETE("/geofences/:id", handlers.DeleteGeofence)
+
+		// Device routes
+		admin.GET("/devices", handlers.GetDevices)
+		admin.POST("/devices", handlers.CreateDevice)
+		admin.GET("/devices/:id", handlers.GetDevice)
+		admin.PUT("/devices/:id", handlers.UpdateDevice)
+		admin.DELETE("/devices/:id", handlers.DeleteDevice)
+		admin.POST("/devices/:id/rotate-key", handlers.RotateDeviceKey)
+		admin.POST("/devices/:id/revoke", handlers.RevokeDevice)
+
+		// Overtime routes
+		signedIn.GET("/overtime", handlers.GetOvertimeRequests)
+		signedIn.POST("/overtime", handlers.CreateOvertimeRequest)
+		signedIn.POST("/overtime/:id/approve", handlers.ApproveOvertimeRequest)
+		signedIn.POST("/overtime/:id/reject", handlers.RejectOvertimeRequest)
+
+		// Holiday routes
+		signedIn.GET("/holidays", handlers.GetHolidays)
+		staff.POST("/holidays", handlers.CreateHoliday)
+		staff.PUT("/holidays/:id", handlers.UpdateHoliday)
+		staff.DELETE("/holidays/:id", handlers.DeleteHoliday)
+
+		// Import job routes
+		staff.GET("/imports", handlers.GetImportJobs)
+		staff.GET("/imports/:id", handlers.GetImportJob)
+
+		// Course routes
+		signedIn.GET("/courses", handlers.GetCourses)
+		admin.POST("/courses", handlers.CreateCourse)
+		signedIn.GET("/courses/:id", handlers.GetCourse)
+		admin.PUT("/courses/:id", handlers.UpdateCourse)
+		admin.DELETE("/courses/:id", handlers.DeleteCourse)
+		admin.GET("/courses/:id/enrollments", handlers.GetCourseEnrollments)
+
+		// Semester routes
+		signedIn.GET("/semesters", handlers.GetSemesters)
+		admin.POST("/semesters", handlers.CreateSemester)
+		signedIn.GET("/semesters/:id", handlers.GetSemester)
+		admin.PUT("/semesters/:id", handlers.UpdateSemester)
+		admin.DELETE("/semesters/:id", handlers.DeleteSemester)
+
+		// Enrollment routes
+		admin.POST("/enrollments", handlers.EnrollStudent)
+		admin.GET("/enrollments/:id", handlers.GetEnrollment)
+		admin.POST("/enrollments/:id/drop", handlers.DropEnrollment)
+		admin.PUT("/enrollments/:id/grade", handlers.SetEnrollmentGrade)
+		admin.DELETE("/enrollments/:id/grade", handlers.DeleteEnrollmentGrade)
+		admin.GET("/students/:id/enrollments", handlers.GetStudentEnrollments)
+		admin.GET("/students/:id/gpa", handlers.GetStudentGPA)
+		signedIn.GET("/grading-scale", handlers.GetGradingScale)
+
+		// Class session routes
+		admin.GET("/class-sessions", handlers.GetClassSessions)
+		admin.POST("/class-sessions", handlers.CreateClassSession)
+		admin.GET("/class-sessions/:id", handlers.GetClassSession)
+		admin.PUT("/class-sessions/:id", handlers.UpdateClassSession)
+		admin.DELETE("/class-sessions/:id", handlers.DeleteClassSession)
+		signedIn.GET("/class-sessions/:id/attendance", handlers.GetSessionAttendance)
+		signedIn.PUT("/class-sessions/:id/attendance", handlers.MarkSessionAttendance)
+		admin.GET("/students/:id/absences", handlers.GetStudentAbsences)
+		admin.GET("/courses/:id/absences", handlers.GetCourseAbsences)
+
+		// Audit routes
+		admin.GET("/audit-events", handlers.GetAuditEvents)
+
+		// Search routes
+		staff.GET("/search", handlers.Search)
+
+		// Report routes
+		staff.GET("/reports/timesheet", handlers.GetTimesheetReport)
 	}
 
 	// Health check
@@ -0,0 +1,316 @@
+// Package audit writes an AuditEvent for every create, update and delete
+// made through GORM. Events are written in the same transaction as the
+// change, so a rolled back change leaves no trace in the audit log.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"project-backend/internal/models"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const beforeKey = "audit:before"
+
+// skippedTables are never audited: the audit log itself, bookkeeping tables
+// and tables that are histories in their own right
+var skippedTables = map[string]bool{
+	"audit_events":               true,
+	"import_jobs":                true,
+	"code_sequences":             true,
+	"employee_status_history":    true,
+	"student_status_history":     true,
+	"department_manager_history": true,
+	"attendance_record_history":  true,
+	"user_tokens":                true,
+	"user_recovery_codes":        true,
+	"idempotency_keys":           true,
+	"device_nonces":              true,
+}
+
+// ignoredColumns change on their own and never make an update worth auditing
+var ignoredColumns = map[string]bool{
+	"failed_logins":     true,
+	"totp_last_counter": true,
+	"updated_at":        true,
+	"last_seen_at":      true,
+}
+
+// redactedColumns are recorded as changed without revealing their values
+var redactedColumns = map[string]bool{
+	"key_hash":        true,
+	"face_descriptor": true,
+	"password_hash":   true,
+	"totp_secret":     true,
+}
+
+const redacted = "[redacted]"
+
+// Register installs the audit callbacks on db
+func Register(db *gorm.DB) error {
+	create := db.Callback().Create()
+	if err := create.After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:create", afterCreate); err != nil {
+		return err
+	}
+
+	update := db.Callback().Update()
+	if err := update.After("gorm:before_update").Before("gorm:update").
+		Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := update.After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:update", afterUpdate); err != nil {
+		return err
+	}
+
+	del := db.Callback().Delete()
+	if err := del.After("gorm:before_delete").Before("gorm:delete").
+		Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	return del.After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:delete", afterDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && !db.DryRun && stmt.Schema != nil &&
+		stmt.Schema.PrioritizedPrimaryField != nil && !skippedTables[stmt.Table]
+}
+
+// captureBefore loads the rows an update or delete is about to change
+func captureBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	conditions := targetConditions(db.Statement)
+	if len(conditions) == 0 {
+		return
+	}
+	rows, err := loadRows(db, conditions)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	ids := primaryKeys(db.Statement)
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := loadRows(db, []clause.Expression{primaryKeyIn(db.Statement, ids)})
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+
+	events := make([]models.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, newEvent(db, models.AuditActionCreate, row, nil, snapshot(row)))
+	}
+	write(db, events)
+}
+
+func afterUpdate(db *gorm.DB) {
+	before := capturedRows(db)
+	if !audited(db) || db.RowsAffected == 0 || len(before) == 0 {
+		return
+	}
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	ids := make([]any, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk])
+	}
+	after, err := loadRows(db, []clause.Expression{primaryKeyIn(db.Statement, ids)})
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+
+	previous := make(map[string]map[string]any, len(before))
+	for _, row := range before {
+		previous[fmt.Sprint(row[pk])] = row
+	}
+	var events []models.AuditEvent
+	for _, row := range after {
+		old, ok := previous[fmt.Sprint(row[pk])]
+		if !ok {
+			continue
+		}
+		oldValues, newValues := diff(old, row)
+		if len(newValues) == 0 {
+			continue
+		}
+		events = append(events, newEvent(db, models.AuditActionUpdate, row, oldValues, newValues))
+	}
+	write(db, events)
+}
+
+func afterDelete(db *gorm.DB) {
+	before := capturedRows(db)
+	if !audited(db) || db.RowsAffected == 0 || len(before) == 0 {
+		return
+	}
+	events := make([]models.AuditEvent, 0, len(before))
+	for _, row := range before {
+		events = append(events, newEvent(db, models.AuditActionDelete, row, snapshot(row), nil))
+	}
+	write(db, events)
+}
+
+func capturedRows(db *gorm.DB) []map[string]any {
+	if v, ok := db.InstanceGet(beforeKey); ok {
+		return v.([]map[string]any)
+	}
+	return nil
+}
+
+// targetConditions rebuilds the WHERE clause of an update or delete: its own
+// conditions, the primary keys of the model value, and the soft delete scope
+func targetConditions(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	if ids := primaryKeys(stmt); len(ids) > 0 {
+		conditions = append(conditions, primaryKeyIn(stmt, ids))
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	if field := stmt.Schema.LookUpField("deleted_at"); field != nil && !stmt.Unscoped &&
+		field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+		conditions = append(conditions, clause.Eq{Column: clause.Column{Table: stmt.Table, Name: "deleted_at"}, Value: nil})
+	}
+	return conditions
+}
+
+// primaryKeys returns the non-zero primary keys of the statement's model value
+func primaryKeys(stmt *gorm.Statement) []any {
+	field := stmt.Schema.PrioritizedPrimaryField
+	var ids []any
+	add := func(v reflect.Value) {
+		if id, zero := field.ValueOf(stmt.Context, v); !zero {
+			ids = append(ids, id)
+		}
+	}
+
+	value := reflect.Indirect(stmt.ReflectValue)
+	switch value.Kind() {
+	case reflect.Struct:
+		add(value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if elem := reflect.Indirect(value.Index(i)); elem.Kind() == reflect.Struct {
+				add(elem)
+			}
+		}
+	}
+	return ids
+}
+
+func primaryKeyIn(stmt *gorm.Statement, ids []any) clause.Expression {
+	column := clause.Column{Table: stmt.Table, Name: stmt.Schema.PrioritizedPrimaryField.DBName}
+	return clause.IN{Column: column, Values: ids}
+}
+
+func loadRows(db *gorm.DB, conditions []clause.Expression) ([]map[string]any, error) {
+	var rows []map[string]any
+	err := db.Session(&gorm.Session{NewDB: true}).
+		Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Find(&rows).Error
+	return rows, err
+}
+
+func newEvent(db *gorm.DB, action models.AuditAction, row map[string]any, before, after models.AuditData) models.AuditEvent {
+	event := models.AuditEvent{
+		Action:     action,
+		EntityType: db.Statement.Table,
+		EntityID:   fmt.Sprint(row[db.Statement.Schema.PrioritizedPrimaryField.DBName]),
+		Before:     before,
+		After:      after,
+	}
+	if info := FromContext(db.Statement.Context); info != nil {
+		if info.Actor != nil {
+			event.ActorType = &info.Actor.Type
+			event.ActorID = &info.Actor.ID
+		}
+		if info.RequestID != "" {
+			event.RequestID = &info.RequestID
+		}
+		if info.IP != "" {
+			event.IP = &info.IP
+		}
+	}
+	return event
+}
+
+func write(db *gorm.DB, events []models.AuditEvent) {
+	if len(events) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&events).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// snapshot converts a row to audit data, redacting sensitive columns
+func snapshot(row map[string]any) models.AuditData {
+	data := make(models.AuditData, len(row))
+	for column, value := range row {
+		data[column] = auditValue(column, value)
+	}
+	return data
+}
+
+// diff returns the old and new values of the columns that changed
+func diff(before, after map[string]any) (models.AuditData, models.AuditData) {
+	oldValues := models.AuditData{}
+	newValues := models.AuditData{}
+	for column, value := range after {
+		if ignoredColumns[column] {
+			continue
+		}
+		previous := before[column]
+		if equalValues(previous, value) {
+			continue
+		}
+		oldValues[column] = auditValue(column, previous)
+		newValues[column] = auditValue(column, value)
+	}
+	return oldValues, newValues
+}
+
+func auditValue(column string, value any) any {
+	if value == nil {
+		return nil
+	}
+	if redactedColumns[column] {
+		return redacted
+	}
+	if b, ok := value.([]byte); ok {
+		if json.Valid(b) {
+			return json.RawMessage(append([]byte(nil), b...))
+		}
+		return string(b)
+	}
+	return value
+}
+
+func equalValues(a, b any) bool {
+	ja, errA := json.Marshal(auditValue("", a))
+	jb, errB := json.Marshal(auditValue("", b))
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,41 @@
+package audit
+
+import "context"
+
+// Actor identifies who performed a change, e.g. a user or a device
+type Actor struct {
+	Type string
+	ID   uint
+}
+
+// Info is the request metadata attached to audit events. It is stored in the
+// request context by pointer so that authentication middleware running later
+// in the chain can still set the actor.
+type Info struct {
+	Actor     *Actor
+	RequestID string
+	IP        string
+}
+
+type contextKey struct{}
+
+// WithInfo returns a copy of ctx carrying info
+func WithInfo(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the audit info of ctx, or nil outside a request
+func FromContext(ctx context.Context) *Info {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(contextKey{}).(*Info)
+	return info
+}
+
+// SetActor records the authenticated actor for the request behind ctx
+func SetActor(ctx context.Context, actor Actor) {
+	if info := FromContext(ctx); info != nil {
+		info.Actor = &actor
+	}
+}
@@ -0,0 +1,26 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// dummyPasswordHash is compared against when no user matches a login so that
+// unknown emails take as long to reject as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash. A nil hash never
+// matches but still costs one bcrypt comparison.
+func CheckPassword(hash *string, password string) bool {
+	if hash == nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(*hash), []byte(password)) == nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// bcrypt ignores everything past 72 bytes, so longer passwords are refused
+// rather than silently truncated
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var bundledBlocklist string
+
+// PasswordPolicy is the set of rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength int
+	blocklist map[string]bool
+}
+
+// NewPasswordPolicy builds a policy from the bundled blocklist, or from the
+// file at blocklistPath when it is not empty
+func NewPasswordPolicy(minLength int, blocklistPath string) (*PasswordPolicy, error) {
+	var r io.Reader = strings.NewReader(bundledBlocklist)
+	if blocklistPath != "" {
+		f, err := os.Open(blocklistPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	blocklist := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &PasswordPolicy{MinLength: minLength, blocklist: blocklist}, nil
+}
+
+// Check returns why password is not acceptable, or nil. Personal values such
+// as the user's email or name may not be used as the password either.
+func (p *PasswordPolicy) Check(password string, personal ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+	}
+	lower := strings.ToLower(password)
+	if p.blocklist[lower] {
+		return fmt.Errorf("password is too common; choose another one")
+	}
+	for _, v := range personal {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		local, _, _ := strings.Cut(v, "@")
+		if lower == v || lower == local {
+			return fmt.Errorf("password must not be your name or email")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateToken returns a random URL-safe token for links sent by email
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex SHA-256 of a token as stored in the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSignedToken returns a random token followed by its HMAC for purpose,
+// so forged or mistyped tokens are rejected before any database lookup
+func GenerateSignedToken(purpose, secret string) (string, error) {
+	token, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	return token + "." + tokenMAC(purpose, token, secret), nil
+}
+
+// VerifySignedToken checks the signature of a token from GenerateSignedToken
+func VerifySignedToken(signed, purpose, secret string) bool {
+	token, mac, ok := strings.Cut(signed, ".")
+	if !ok || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(tokenMAC(purpose, token, secret)))
+}
+
+func tokenMAC(purpose, token, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + "\n" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Patterns are plain text with placeholders:
+//
+//	{dept}   department code
+//	{yyyy}   four digit year, {yy} two digit year, {mm} month
+//	{seq:N}  sequence number zero padded to N digits ({seq} for no padding)
+//
+// e.g. "EMP-{dept}-{yyyy}-{seq:5}" or "SV{seq:3}". Each distinct expansion of
+// the non-sequence placeholders is its own scope with its own counter, so
+// EMP-IT-2026-00001 and EMP-HR-2026-00001 can coexist.
+var placeholder = regexp.MustCompile(`\{(dept|yyyy|yy|mm|seq(?::(\d+))?)\}`)
+
+// maxAttempts bounds the retries when a generated code is already taken,
+// e.g. by a code typed in by hand before the generator was enabled
+const maxAttempts = 50
+
+// Vars are the values substituted into a pattern
+type Vars struct {
+	Dept string
+	Time time.Time
+}
+
+// Validate checks that a pattern contains exactly one sequence placeholder
+func Validate(pattern string) error {
+	seqs := 0
+	for _, m := range placeholder.FindAllStringSubmatch(pattern, -1) {
+		if strings.HasPrefix(m[1], "seq") {
+			seqs++
+		}
+	}
+	if seqs != 1 {
+		return fmt.Errorf("code pattern %q must contain exactly one {seq} placeholder", pattern)
+	}
+	return nil
+}
+
+// Counter hands out sequence numbers per scope
+type Counter interface {
+	Next(scope string) (int64, error)
+}
+
+type dbCounter struct {
+	db *gorm.DB
+}
+
+// DBCounter increments the scope's counter in code_sequences with a single
+// upsert, so concurrent callers always draw different numbers. The counter
+// row stays locked until db's transaction ends, so long-running callers
+// should pass a connection outside their transaction.
+func DBCounter(db *gorm.DB) Counter {
+	return dbCounter{db: db}
+}
+
+func (c dbCounter) Next(scope string) (int64, error) {
+	var seq int64
+	err := c.db.Raw(`INSERT INTO code_sequences (scope, value, updated_at) VALUES (?, 1, NOW())
+		ON CONFLICT (scope) DO UPDATE SET value = code_sequences.value + 1, updated_at = NOW()
+		RETURNING value`, scope).Scan(&seq).Error
+	return seq, err
+}
+
+type previewCounter struct {
+	db   *gorm.DB
+	next map[string]int64
+}
+
+// PreviewCounter continues from the stored counters without writing them,
+// for dry runs that must not consume or lock sequence numbers
+func PreviewCounter(db *gorm.DB) Counter {
+	return &previewCounter{db: db, next: map[string]int64{}}
+}
+
+func (c *previewCounter) Next(scope string) (int64, error) {
+	seq, ok := c.next[scope]
+	if !ok {
+		var stored int64
+		err := c.db.Raw(`SELECT COALESCE(MAX(value), 0) FROM code_sequences WHERE scope = ?`, scope).Scan(&stored).Error
+		if err != nil {
+			return 0, err
+		}
+		seq = stored
+	}
+	seq++
+	c.next[scope] = seq
+	return seq, nil
+}
+
+// Generate returns the next free code for pattern, drawing sequence numbers
+// from counter; exists is consulted to skip codes that are already in use.
+func Generate(counter Counter, pattern string, vars Vars, exists func(code string) (bool, error)) (string, error) {
+	if err := Validate(pattern); err != nil {
+		return "", err
+	}
+	scope := expand(pattern, vars, -1)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		seq, err := counter.Next(scope)
+		if err != nil {
+			return "", err
+		}
+
+		code := expand(pattern, vars, seq)
+		taken, err := exists(code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("no free code found for pattern %q after %d attempts", pattern, maxAttempts)
+}
+
+// expand substitutes vars into pattern. A negative seq leaves the sequence
+// placeholder in place, which yields the scope key.
+func expand(pattern string, vars Vars, seq int64) string {
+	return placeholder.ReplaceAllStringFunc(pattern, func(token string) string {
+		m := placeholder.FindStringSubmatch(token)
+		switch m[1] {
+		case "dept":
+			return vars.Dept
+		case "yyyy":
+			return vars.Time.Format("2006")
+		case "yy":
+			return vars.Time.Format("06")
+		case "mm":
+			return vars.Time.Format("01")
+		}
+		if seq < 0 {
+			return token
+		}
+		width, _ := strconv.Atoi(m[2])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"log"
 	"os"
 
+	"project-backend/internal/audit"
+	"project-backend/internal/models"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -28,12 +31,108 @@ func Connect() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if err := audit.Register(DB); err != nil {
+		log.Fatal("Failed to register audit callbacks:", err)
+	}
+
 	log.Println("Database connected successfully")
 }
 
 func Migrate() {
-	// Auto migrate your models here
-	// Uncomment the line below after importing models
-	// DB.AutoMigrate(&models.User{})
+	// Extensions and enum types used by the models must exist before AutoMigrate
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		`DO $$ BEGIN
+			CREATE TYPE employee_status AS ENUM ('active', 'inactive', 'suspended');
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+		`ALTER TYPE employee_status ADD VALUE IF NOT EXISTS 'terminated'`,
+		// AutoMigrate only adds missing check constraints; drop this one so
+		// it is recreated with the current list of statuses
+		`ALTER TABLE IF EXISTS students DROP CONSTRAINT IF EXISTS chk_students_status`,
+		`CREATE EXTENSION IF NOT EXISTS unaccent`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		// unaccent() is only STABLE; an IMMUTABLE wrapper can be used in indexes
+		`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+			$$ SELECT public.unaccent('public.unaccent', $1) $$
+			LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+	}
+	runStatements("Failed to prepare database:", statements)
+
+	err := DB.AutoMigrate(
+		&models.Student{},
+		&models.StudentStatusHistory{},
+		&models.StudentRollover{},
+		&models.CodeSequence{},
+		&models.ImportJob{},
+		&models.Shift{},
+		&models.Department{},
+		&models.Employee{},
+		&models.DepartmentManagerHistory{},
+		&models.EmployeeStatusHistory{},
+		&models.Device{},
+		&models.DeviceNonce{},
+		&models.Geofence{},
+		&models.AttendanceRecord{},
+		&models.LeaveRequest{},
+		&models.Holiday{},
+		&models.OvertimeRequest{},
+		&models.AttendanceCorrection{},
+		&models.AttendanceCorrectionAttachment{},
+		&models.AttendanceRecordHistory{},
+		&models.AuditEvent{},
+		&models.Course{},
+		&models.Semester{},
+		&models.Enrollment{},
+		&models.Grade{},
+		&models.ClassSession{},
+		&models.StudentAttendance{},
+		&models.User{},
+		&models.UserToken{},
+		&models.UserRecoveryCode{},
+		&models.RateLimitBucket{},
+		&models.IdempotencyKey{},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	// Trigram indexes over the accent-folded search documents used by /search
+	runStatements("Failed to create search indexes:", []string{
+		`CREATE INDEX IF NOT EXISTS idx_students_search ON students
+			USING gin (` + StudentSearchDocument + ` gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_employees_search ON employees
+			USING gin (` + EmployeeSearchDocument + ` gin_trgm_ops)`,
+	})
+
+	// The audit log is append-only, even for the application's own role
+	runStatements("Failed to protect audit log:", []string{
+		`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END $$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+		`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`,
+	})
+
 	log.Println("Database migration completed")
 }
+
+// Search documents fold names, emails, codes and phone numbers into one
+// lower-case, accent-free string, so "nguyen van an" finds "Nguyễn Văn An".
+// Queries must use the exact same expression for the indexes to apply.
+const (
+	StudentSearchDocument = `f_unaccent(lower(first_name || ' ' || last_name || ' ' ||
+		email || ' ' || student_code || ' ' || coalesce(phone, '')))`
+	EmployeeSearchDocument = `f_unaccent(lower(first_name || ' ' || last_name || ' ' ||
+		email || ' ' || coalesce(employee_id, '') || ' ' || coalesce(phone, '')))`
+)
+
+func runStatements(message string, statements []string) {
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Fatal(message, err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+)
+
+// utf8BOM lets Excel detect UTF-8 in CSV files, so Vietnamese names such as
+// "Nguyễn Văn An" are not shown as mojibake
+const utf8BOM = "\xEF\xBB\xBF"
+
+// Options tune the output of a Writer
+type Options struct {
+	// BOM prefixes CSV output with a UTF-8 byte order mark for Excel
+	BOM bool
+}
+
+// Writer streams tabular rows in one of the supported output formats
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []any) error
+	Close() error
+}
+
+// NewWriter returns a Writer for the given format writing to w
+func NewWriter(format string, w io.Writer, opts Options) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatJSONL:
+		return &jsonlWriter{w: w}, nil
+	case FormatCSV:
+		if opts.BOM {
+			if _, err := io.WriteString(w, utf8BOM); err != nil {
+				return nil, err
+			}
+		}
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ContentType returns the HTTP content type for the given format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSONL:
+		return "application/x-ndjson; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// IsSupported reports whether format is a known export format
+func IsSupported(format string) bool {
+	switch format {
+	case FormatJSON, FormatJSONL, FormatCSV, FormatXLSX:
+		return true
+	}
+	return false
+}
+
+// csvWriter writes rows as RFC 4180 CSV, flushing after every row
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) WriteHeader(columns []string) error {
+	return cw.writeRecord(columns)
+}
+
+func (cw *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v)
+	}
+	return cw.writeRecord(record)
+}
+
+func (cw *csvWriter) writeRecord(record []string) error {
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonWriter writes {"data":[{...},...],"count":n} one object at a time
+type jsonWriter struct {
+	w       io.Writer
+	columns []string
+	count   int
+}
+
+func (jw *jsonWriter) WriteHeader(columns []string) error {
+	jw.columns = columns
+	_, err := io.WriteString(jw.w, `{"data":[`)
+	return err
+}
+
+func (jw *jsonWriter) WriteRow(values []any) error {
+	if jw.count > 0 {
+		if _, err := io.WriteString(jw.w, ","); err != nil {
+			return err
+		}
+	}
+	b, err := marshalRow(jw.columns, values)
+	if err != nil {
+		return err
+	}
+	jw.count++
+	_, err = jw.w.Write(b)
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	_, err := fmt.Fprintf(jw.w, `],"count":%d}`, jw.count)
+	return err
+}
+
+// jsonlWriter writes one JSON object per line (JSON Lines)
+type jsonlWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+func (jw *jsonlWriter) WriteHeader(columns []string) error {
+	jw.columns = columns
+	return nil
+}
+
+func (jw *jsonlWriter) WriteRow(values []any) error {
+	b, err := marshalRow(jw.columns, values)
+	if err != nil {
+		return err
+	}
+	_, err = jw.w.Write(append(b, '\n'))
+	return err
+}
+
+func (jw *jsonlWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter writes a single-sheet workbook using excelize's stream writer,
+// which spills rows to a temporary file instead of holding them in memory
+type xlsxWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: file, sw: sw}, nil
+}
+
+func (xw *xlsxWriter) WriteHeader(columns []string) error {
+	values := make([]any, len(columns))
+	for i, c := range columns {
+		values[i] = c
+	}
+	return xw.WriteRow(values)
+}
+
+func (xw *xlsxWriter) WriteRow(values []any) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	row := make([]any, len(values))
+	for i, v := range values {
+		row[i] = xlsxValue(v)
+	}
+	return xw.sw.SetRow(cell, row)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.sw.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.w)
+}
+
+// marshalRow encodes a row as a JSON object, keeping keys in column order
+func marshalRow(columns []string, values []any) ([]byte, error) {
+	buf := []byte{'{'}
+	for i, c := range columns {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(c)
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		var v any
+		if i < len(values) {
+			v = values[i]
+		}
+		val, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, val...)
+	}
+	return append(buf, '}'), nil
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *string:
+		if val == nil {
+			return ""
+		}
+		return *val
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	case float64:
+		return fmt.Sprintf("%.2f", val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func xlsxValue(v any) any {
+	switch val := v.(type) {
+	case *string:
+		if val == nil {
+			return nil
+		}
+		return *val
+	case *time.Time:
+		if val == nil {
+			return nil
+		}
+		return *val
+	default:
+		return val
+	}
+}
@@ -0,0 +1,259 @@
+// Package filter compiles small filter expressions such as
+//
+//	status:active AND department:IT AND join_date>=2024-01-01
+//
+// into SQL conditions over a whitelist of fields. Values are always passed as
+// bind parameters; only the whitelisted column expressions reach the SQL text.
+//
+// Comparisons are field, operator, value. Operators are ":" and "=" (equal),
+// "!=", "<", "<=", ">", ">=" and "~" (contains, strings only). Values are bare
+// words or double-quoted strings; the bare word null matches missing values.
+// Conditions combine with AND, OR, NOT and parentheses, and adjacent
+// conditions without an operator are ANDed.
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type decides how a field's values are parsed and which operators apply
+type Type int
+
+const (
+	String Type = iota
+	Number
+	Date
+	Enum
+)
+
+// Field is a filterable column. Column is the SQL expression used in the
+// condition; Values lists the allowed values of an Enum field.
+type Field struct {
+	Column string
+	Type   Type
+	Values []string
+}
+
+// Fields is the whitelist of fields an expression may use, keyed by name
+type Fields map[string]Field
+
+// Error is an invalid expression, with Pos the 1-based character position
+// where the problem was found
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Msg)
+}
+
+// Compile parses input against fields and returns a SQL condition with its
+// bind arguments. An empty input compiles to an empty condition.
+func Compile(input string, fields Fields) (string, []any, error) {
+	if strings.TrimSpace(input) == "" {
+		return "", nil, nil
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	sql, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return "", nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return sql, p.args, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	fields Fields
+	args   []any
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// parseOr handles: and (OR and)*
+func (p *parser) parseOr() (string, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+	for p.peek().kind == tokenOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " OR " + right + ")"
+	}
+	return left, nil
+}
+
+// parseAnd handles: unary (AND? unary)*
+func (p *parser) parseAnd() (string, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return "", err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.advance()
+		case tokenWord, tokenNot, tokenLParen:
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " AND " + right + ")"
+	}
+}
+
+// parseUnary handles: NOT unary | "(" or ")" | comparison
+func (p *parser) parseUnary() (string, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		return "(NOT " + inner + ")", nil
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if closing := p.advance(); closing.kind != tokenRParen {
+			return "", &Error{Pos: closing.pos, Msg: "expected )"}
+		}
+		return inner, nil
+	case tokenWord:
+		return p.parseComparison(tok)
+	case tokenEOF:
+		return "", &Error{Pos: tok.pos, Msg: "unexpected end of filter"}
+	default:
+		return "", &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a field name, got %q", tok.text)}
+	}
+}
+
+func (p *parser) parseComparison(name token) (string, error) {
+	field, ok := p.fields[strings.ToLower(name.text)]
+	if !ok {
+		return "", &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q (allowed: %s)", name.text, p.fieldNames())}
+	}
+
+	op := p.advance()
+	if op.kind != tokenOp {
+		return "", &Error{Pos: op.pos, Msg: fmt.Sprintf("expected an operator after %q", name.text)}
+	}
+	value := p.advance()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return "", &Error{Pos: value.pos, Msg: fmt.Sprintf("expected a value after %q", op.text)}
+	}
+
+	if value.kind == tokenWord && strings.EqualFold(value.text, "null") {
+		switch op.text {
+		case ":", "=":
+			return field.Column + " IS NULL", nil
+		case "!=":
+			return field.Column + " IS NOT NULL", nil
+		}
+		return "", &Error{Pos: op.pos, Msg: fmt.Sprintf("operator %q cannot be used with null", op.text)}
+	}
+
+	switch field.Type {
+	case String:
+		return p.compareString(field, op, value)
+	case Number:
+		n, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return "", &Error{Pos: value.pos, Msg: fmt.Sprintf("%q is not a number", value.text)}
+		}
+		return p.compareOrdered(field.Column, op, n)
+	case Date:
+		d, err := time.Parse("2006-01-02", value.text)
+		if err != nil {
+			return "", &Error{Pos: value.pos, Msg: fmt.Sprintf("%q is not a date (YYYY-MM-DD)", value.text)}
+		}
+		return p.compareOrdered("CAST("+field.Column+" AS date)", op, d.Format("2006-01-02"))
+	case Enum:
+		for _, allowed := range field.Values {
+			if strings.EqualFold(allowed, value.text) {
+				return p.compareEqual(field.Column, op, allowed)
+			}
+		}
+		return "", &Error{Pos: value.pos, Msg: fmt.Sprintf("%q is not one of %s", value.text, strings.Join(field.Values, ", "))}
+	}
+	return "", &Error{Pos: name.pos, Msg: fmt.Sprintf("field %q has an unsupported type", name.text)}
+}
+
+// compareString matches strings case-insensitively; "~" is a substring match
+func (p *parser) compareString(field Field, op, value token) (string, error) {
+	switch op.text {
+	case "~":
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value.text)
+		p.args = append(p.args, "%"+escaped+"%")
+		return field.Column + " ILIKE ?", nil
+	case ":", "=":
+		p.args = append(p.args, value.text)
+		return "lower(" + field.Column + ") = lower(?)", nil
+	case "!=":
+		p.args = append(p.args, value.text)
+		return "lower(" + field.Column + ") IS DISTINCT FROM lower(?)", nil
+	}
+	return p.compareOrdered(field.Column, op, value.text)
+}
+
+func (p *parser) compareOrdered(column string, op token, value any) (string, error) {
+	switch op.text {
+	case "<", "<=", ">", ">=":
+		p.args = append(p.args, value)
+		return column + " " + op.text + " ?", nil
+	}
+	return p.compareEqual(column, op, value)
+}
+
+func (p *parser) compareEqual(column string, op token, value any) (string, error) {
+	switch op.text {
+	case ":", "=":
+		p.args = append(p.args, value)
+		return column + " = ?", nil
+	case "!=":
+		p.args = append(p.args, value)
+		return column + " IS DISTINCT FROM ?", nil
+	}
+	return "", &Error{Pos: op.pos, Msg: fmt.Sprintf("operator %q is not supported for this field", op.text)}
+}
+
+func (p *parser) fieldNames() string {
+	names := make([]string, 0, len(p.fields))
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
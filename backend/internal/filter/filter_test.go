@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testFields = Fields{
+	"name":      {Column: "name", Type: String},
+	"gpa":       {Column: "gpa", Type: Number},
+	"join_date": {Column: "join_date", Type: Date},
+	"status":    {Column: "status", Type: Enum, Values: []string{"active", "suspended"}},
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		sql   string
+		args  []any
+	}{
+		{"empty", "  ", "", nil},
+		{"enum equal", "status:active", "status = ?", []any{"active"}},
+		{"enum is case-insensitive", "status=ACTIVE", "status = ?", []any{"active"}},
+		{"enum not equal", "status!=suspended", "status IS DISTINCT FROM ?", []any{"suspended"}},
+		{"string equal", "name:An", "lower(name) = lower(?)", []any{"An"}},
+		{"string not equal", "name!=An", "lower(name) IS DISTINCT FROM lower(?)", []any{"An"}},
+		{"string contains", `name~"50%_off"`, "name ILIKE ?", []any{`%50\%\_off%`}},
+		{"quoted string with escape", `name:"Nguyễn \"Bé\""`, "lower(name) = lower(?)", []any{`Nguyễn "Bé"`}},
+		{"number", "gpa>=3.2", "gpa >= ?", []any{3.2}},
+		{"date", "join_date<2024-01-01", "CAST(join_date AS date) < ?", []any{"2024-01-01"}},
+		{"null", "join_date:null", "join_date IS NULL", nil},
+		{"not null", "join_date!=NULL", "join_date IS NOT NULL", nil},
+		{"field names are case-insensitive", "GPA>3", "gpa > ?", []any{3.0}},
+		{"implicit and", "status:active gpa>3", "(status = ? AND gpa > ?)", []any{"active", 3.0}},
+		{
+			"and binds tighter than or",
+			"status:active OR status:suspended AND gpa<2",
+			"(status = ? OR (status = ? AND gpa < ?))",
+			[]any{"active", "suspended", 2.0},
+		},
+		{
+			"parentheses and not",
+			"(name:An or name:Binh) and not status:suspended",
+			"((lower(name) = lower(?) OR lower(name) = lower(?)) AND (NOT status = ?))",
+			[]any{"An", "Binh", "suspended"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := Compile(tt.input, testFields)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tt.input, err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"unknown field", "status:active AND foo:1", 19},
+		{"missing operator", "status active", 8},
+		{"missing value", "status:", 8},
+		{"value not allowed", "status:gone", 8},
+		{"not a number", "gpa>high", 5},
+		{"not a date", "join_date>=01/02/2024", 12},
+		{"operator not supported", "status~active", 7},
+		{"ordered null", "gpa>null", 4},
+		{"unclosed parenthesis", "(status:active", 15},
+		{"unexpected closing parenthesis", "status:active)", 14},
+		{"unterminated string", `name:"An`, 6},
+		{"unexpected character", "name:An !x", 9},
+		{"dangling and", "status:active AND", 18},
+		{"positions count characters, not bytes", "name:Đức foo:1", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Compile(tt.input, testFields)
+			var ferr *Error
+			if !errors.As(err, &ferr) {
+				t.Fatalf("Compile(%q) error = %v, want *Error", tt.input, err)
+			}
+			if ferr.Pos != tt.pos {
+				t.Errorf("Compile(%q) position = %d, want %d (%s)", tt.input, ferr.Pos, tt.pos, ferr.Msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // meters
+		tol  float64
+	}{
+		{"same point", Point{21.0285, 105.8542}, Point{21.0285, 105.8542}, 0, 0.001},
+		// One degree of latitude is 2πR/360 on a sphere of radius R
+		{"one degree of latitude", Point{0, 0}, Point{1, 0}, 2 * math.Pi * earthRadiusMeters / 360, 0.001},
+		{"quarter of the equator", Point{0, 0}, Point{0, 90}, math.Pi * earthRadiusMeters / 2, 0.001},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, math.Pi * earthRadiusMeters, 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("Distance = %.3f m, want %.3f ± %.3f", got, tt.want, tt.tol)
+			}
+			if got, back := Distance(tt.a, tt.b), Distance(tt.b, tt.a); math.Abs(got-back) > 1e-6 {
+				t.Errorf("Distance is not symmetric: %f and %f", got, back)
+			}
+		})
+	}
+}
+
+func TestInCircle(t *testing.T) {
+	center := Point{21.0285, 105.8542}
+	// 0.001° of latitude is about 111 m
+	near := Point{21.0294, 105.8542}
+	if !InCircle(near, center, 150) {
+		t.Error("point about 100 m away is outside a 150 m circle")
+	}
+	if InCircle(near, center, 50) {
+		t.Error("point about 100 m away is inside a 50 m circle")
+	}
+}
+
+func TestInPolygon(t *testing.T) {
+	square := []Point{{0, 0}, {0, 1}, {1, 1}, {1, 0}}
+	// A concave "L": the square with its top-right quarter cut away
+	lShape := []Point{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+
+	tests := []struct {
+		name    string
+		p       Point
+		polygon []Point
+		want    bool
+	}{
+		{"center", Point{0.5, 0.5}, square, true},
+		{"near a corner inside", Point{0.999, 0.999}, square, true},
+		{"outside", Point{1.5, 0.5}, square, false},
+		{"outside beside a vertex", Point{-0.001, 0.5}, square, false},
+		{"ray passes through a vertex", Point{0.5, -1}, square, false},
+		{"inside the L", Point{1.5, 0.5}, lShape, true},
+		{"in the L's notch", Point{1.5, 1.5}, lShape, false},
+		{"too few points", Point{0, 0}, []Point{{0, 0}, {1, 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InPolygon(tt.p, tt.polygon); got != tt.want {
+				t.Errorf("InPolygon(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, p := range []Point{{90, 180}, {-90, -180}, {21.0285, 105.8542}} {
+		if !Valid(p) {
+			t.Errorf("Valid(%v) = false", p)
+		}
+	}
+	for _, p := range []Point{{90.1, 0}, {0, -180.1}} {
+		if Valid(p) {
+			t.Errorf("Valid(%v) = true", p)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package grading converts between the Vietnamese 10-point score, letter
+// grades and 4.0-scale grade points.
+package grading
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Band is one letter grade: scores from MinScore (10-point scale) up to the
+// next band earn Letter and Points
+type Band struct {
+	Letter   string  `json:"letter"`
+	MinScore float64 `json:"min_score"`
+	Points   float64 `json:"points"`
+}
+
+// Passing reports whether the band earns credit
+func (b Band) Passing() bool {
+	return b.Points > 0
+}
+
+// Scale is a grading scale ordered from the highest band down
+type Scale []Band
+
+// DefaultSpec is the credit-system scale used by most Vietnamese universities
+const DefaultSpec = "A:8.5:4.0,B+:8.0:3.5,B:7.0:3.0,C+:6.5:2.5,C:5.5:2.0,D+:5.0:1.5,D:4.0:1.0,F:0:0"
+
+// Parse reads a scale written as comma-separated letter:min_score:points
+// bands, e.g. "A:8.5:4.0,B:7.0:3.0,F:0:0". The lowest band must start at 0.
+func Parse(spec string) (Scale, error) {
+	var scale Scale
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 3 || fields[0] == "" {
+			return nil, fmt.Errorf("grading scale: band %q must be letter:min_score:points", part)
+		}
+		minScore, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || minScore < 0 || minScore > 10 {
+			return nil, fmt.Errorf("grading scale: band %q has an invalid min_score", part)
+		}
+		points, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || points < 0 || points > 4 {
+			return nil, fmt.Errorf("grading scale: band %q has invalid points", part)
+		}
+		scale = append(scale, Band{Letter: strings.ToUpper(fields[0]), MinScore: minScore, Points: points})
+	}
+
+	sort.SliceStable(scale, func(i, j int) bool { return scale[i].MinScore > scale[j].MinScore })
+	for i := 1; i < len(scale); i++ {
+		if scale[i].MinScore == scale[i-1].MinScore {
+			return nil, fmt.Errorf("grading scale: bands %s and %s share a min_score", scale[i-1].Letter, scale[i].Letter)
+		}
+	}
+	if len(scale) == 0 || scale[len(scale)-1].MinScore != 0 {
+		return nil, fmt.Errorf("grading scale: the lowest band must start at 0")
+	}
+	return scale, nil
+}
+
+// FromScore returns the band of a 10-point score
+func (s Scale) FromScore(score float64) (Band, error) {
+	if score < 0 || score > 10 || math.IsNaN(score) {
+		return Band{}, fmt.Errorf("score must be between 0 and 10")
+	}
+	for _, band := range s {
+		if score >= band.MinScore {
+			return band, nil
+		}
+	}
+	return s[len(s)-1], nil
+}
+
+// FromLetter returns the band of a letter grade
+func (s Scale) FromLetter(letter string) (Band, error) {
+	for _, band := range s {
+		if strings.EqualFold(band.Letter, letter) {
+			return band, nil
+		}
+	}
+	return Band{}, fmt.Errorf("unknown letter grade %q", letter)
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errAlreadyCheckedIn = errors.New("employee already checked in today")
+	errNotCheckedIn     = errors.New("no check-in found for today")
+	errOutOfDeviceScope = errors.New("employee is outside the device's department")
+	errNotActive        = errors.New("employee is not active")
+)
+
+// attendanceSource describes where a check-in or check-out came from
+type attendanceSource struct {
+	Device   *models.Device
+	Location *geoFix // GPS fix for mobile check-ins, nil for kiosks
+}
+
+func deviceSource(c *gin.Context) attendanceSource {
+	return attendanceSource{Device: middleware.CurrentDevice(c)}
+}
+
+type attendanceRequest struct {
+	EmployeeID uint `json:"employee_id" binding:"required"`
+}
+
+// CheckIn records the first arrival of an employee for today
+func CheckIn(c *gin.Context) {
+	var req attendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.Preload("Shift").First(&employee, req.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	record, err := checkInEmployee(c.Request.Context(), employee, deviceSource(c), time.Now())
+	if err != nil {
+		respondAttendanceError(c, err, record)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+// CheckOut records the departure of an employee on today's attendance record
+func CheckOut(c *gin.Context) {
+	var req attendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.Preload("Shift").First(&employee, req.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	record, err := checkOutEmployee(c.Request.Context(), employee, deviceSource(c), time.Now())
+	if err != nil {
+		respondAttendanceError(c, err, record)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+type faceMatchRequest struct {
+	Descriptor []float64 `json:"descriptor" binding:"required,min=1"`
+	Action     string    `json:"action" binding:"omitempty,oneof=auto check_in check_out"`
+}
+
+// FaceMatch identifies an employee from a face descriptor captured by a kiosk
+// and checks them in, or out when they already checked in today
+func FaceMatch(c *gin.Context) {
+	var req faceMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source := deviceSource(c)
+	employee, distance, err := matchFace(req.Descriptor, source.Device)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No matching employee"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	action := req.Action
+	if action == "" || action == "auto" {
+		var count int64
+		database.DB.Model(&models.AttendanceRecord{}).
+			Where("employee_id = ? AND date = ?", employee.ID, truncateToDate(time.Now())).
+			Count(&count)
+		action = "check_in"
+		if count > 0 {
+			action = "check_out"
+		}
+	}
+
+	var record models.AttendanceRecord
+	if action == "check_in" {
+		record, err = checkInEmployee(c.Request.Context(), employee, source, time.Now())
+	} else {
+		record, err = checkOutEmployee(c.Request.Context(), employee, source, time.Now())
+	}
+	if err != nil {
+		respondAttendanceError(c, err, record)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     record,
+		"employee": employee,
+		"action":   action,
+		"distance": distance,
+	})
+}
+
+// matchFace returns the closest active employee whose stored face descriptor is
+// within FACE_MATCH_MAX_DISTANCE of the given one
+func matchFace(descriptor []float64, device *models.Device) (models.Employee, float64, error) {
+	var match struct {
+		ID       uint
+		Distance float64
+	}
+	var employee models.Employee
+
+	query := database.DB.Model(&models.Employee{}).
+		Select("id, face_descriptor <-> ?::vector AS distance", vectorLiteral(descriptor)).
+		Where("face_descriptor IS NOT NULL AND status = ?", models.EmployeeStatusActive)
+	if device != nil && device.DepartmentID != nil {
+		query = query.Where("department_id IN (?)", database.DB.Raw(subtreeQuery, []uint{*device.DepartmentID}))
+	}
+	if err := query.Order("distance").Limit(1).Scan(&match).Error; err != nil {
+		return employee, 0, err
+	}
+	if match.ID == 0 || match.Distance > config.Float("FACE_MATCH_MAX_DISTANCE", 0.6) {
+		return employee, match.Distance, gorm.ErrRecordNotFound
+	}
+
+	err := database.DB.Preload("Shift").First(&employee, match.ID).Error
+	return employee, match.Distance, err
+}
+
+// vectorLiteral formats a descriptor as a pgvector literal, e.g. [0.1,0.2]
+func vectorLiteral(v []float64) string {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// deviceAllows reports whether a device may record attendance for an employee.
+// A device scoped to a department also serves its child departments.
+func deviceAllows(device *models.Device, employee models.Employee) (bool, error) {
+	if device == nil || device.DepartmentID == nil {
+		return true, nil
+	}
+	if employee.DepartmentID == nil {
+		return false, nil
+	}
+	scope, err := departmentSubtreeIDs(*device.DepartmentID)
+	if err != nil {
+		return false, err
+	}
+	return containsID(scope, *employee.DepartmentID), nil
+}
+
+// checkInEmployee creates today's attendance record for the employee
+func checkInEmployee(ctx context.Context, employee models.Employee, source attendanceSource, now time.Time) (models.AttendanceRecord, error) {
+	record := models.AttendanceRecord{
+		EmployeeID: employee.ID,
+		Date:       truncateToDate(now),
+		CheckIn:    &now,
+		Status:     models.AttendanceStatusPresent,
+	}
+	// Suspended and terminated employees can no longer check in
+	if employee.Status != models.EmployeeStatusActive {
+		return record, errNotActive
+	}
+	if allowed, err := deviceAllows(source.Device, employee); err != nil {
+		return record, err
+	} else if !allowed {
+		return record, errOutOfDeviceScope
+	}
+	if source.Device != nil {
+		record.DeviceID = &source.Device.ID
+	}
+	if fix := source.Location; fix != nil {
+		record.Latitude = &fix.Point.Lat
+		record.Longitude = &fix.Point.Lng
+		record.AccuracyMeters = &fix.Accuracy
+		record.GeofenceID = fix.GeofenceID
+		fix.flag(&record)
+	}
+
+	if employee.Shift != nil {
+		if late := lateMinutes(*employee.Shift, now); late > 0 {
+			record.LateMinutes = late
+			record.Status = models.AttendanceStatusLate
+		}
+	}
+
+	var existing models.AttendanceRecord
+	err := database.DB.Where("employee_id = ? AND date = ?", record.EmployeeID, record.Date).First(&existing).Error
+	if err == nil {
+		return existing, errAlreadyCheckedIn
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, err
+	}
+
+	err = database.DB.WithContext(ctx).Create(&record).Error
+	return record, err
+}
+
+// checkOutEmployee stamps the departure on today's record and detects overtime
+func checkOutEmployee(ctx context.Context, employee models.Employee, source attendanceSource, now time.Time) (models.AttendanceRecord, error) {
+	var record models.AttendanceRecord
+	if allowed, err := deviceAllows(source.Device, employee); err != nil {
+		return record, err
+	} else if !allowed {
+		return record, errOutOfDeviceScope
+	}
+	if err := database.DB.Where("employee_id = ? AND date = ?", employee.ID, truncateToDate(now)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, errNotCheckedIn
+		}
+		return record, err
+	}
+
+	record.CheckOut = &now
+	if source.Device != nil {
+		record.CheckOutDeviceID = &source.Device.ID
+	}
+	if fix := source.Location; fix != nil {
+		record.CheckOutLatitude = &fix.Point.Lat
+		record.CheckOutLongitude = &fix.Point.Lng
+		fix.flag(&record)
+	}
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&record).Error; err != nil {
+			return err
+		}
+		return recomputeAttendance(tx, employee.Shift, &record)
+	})
+	return record, err
+}
+
+// respondAttendanceError maps check-in/out errors to HTTP responses
+func respondAttendanceError(c *gin.Context, err error, record models.AttendanceRecord) {
+	switch {
+	case errors.Is(err, errAlreadyCheckedIn):
+		c.JSON(http.StatusConflict, gin.H{"error": "Employee already checked in today", "data": record})
+	case errors.Is(err, errNotCheckedIn):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No check-in found for today"})
+	case errors.Is(err, errNotActive):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Employee is not active"})
+	case errors.Is(err, errOutOfDeviceScope):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Employee is outside this device's department"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetAttendanceRecords retrieves attendance records filtered by employee and date range
+func GetAttendanceRecords(c *gin.Context) {
+	query := database.DB.Preload("Employee").Order("date DESC, employee_id")
+
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	if from := c.Query("from"); from != "" {
+		query = query.Where("date >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("date <= ?", to)
+	}
+	if c.Query("flagged") == "true" {
+		query = query.Where("flagged = ?", true)
+	}
+
+	var records []models.AttendanceRecord
+	if err := query.Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"count": len(records),
+	})
+}
+
+// recomputeAttendance refreshes the late and overtime figures of a saved record
+// from its check-in and check-out times
+func recomputeAttendance(tx *gorm.DB, shift *models.Shift, record *models.AttendanceRecord) error {
+	record.Status = models.AttendanceStatusPresent
+	record.LateMinutes = 0
+	if shift != nil && record.CheckIn != nil {
+		if late := lateMinutes(*shift, *record.CheckIn); late > 0 {
+			record.LateMinutes = late
+			record.Status = models.AttendanceStatusLate
+		}
+	}
+
+	if err := detectOvertime(tx, shift, record); err != nil {
+		return err
+	}
+
+	return tx.Model(record).Select("status", "late_minutes", "overtime_minutes").Updates(record).Error
+}
+
+// GetAttendanceHistory retrieves the pre-correction values of an attendance record
+func GetAttendanceHistory(c *gin.Context) {
+	id := c.Param("id")
+	var record models.AttendanceRecord
+	if err := database.DB.First(&record, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+
+	var history []models.AttendanceRecordHistory
+	if err := database.DB.Where("attendance_record_id = ?", record.ID).Order("created_at").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    history,
+		"count":   len(history),
+		"current": record,
+	})
+}
+
+// truncateToDate drops the clock part of t in its own location
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// shiftClock returns the moment on t's day matching an "HH:MM" shift time
+func shiftClock(t time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), parsed.Hour(), parsed.Minute(), 0, 0, t.Location()), nil
+}
+
+// lateMinutes returns how many minutes after the shift start (plus grace) t is
+func lateMinutes(shift models.Shift, t time.Time) int {
+	start, err := shiftClock(t, shift.StartTime)
+	if err != nil {
+		return 0
+	}
+	late := int(t.Sub(start).Minutes())
+	if late <= shift.GraceMinutes {
+		return 0
+	}
+	return late
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// pagination reads ?page= (1-based) and ?page_size=
+func pagination(c *gin.Context) (page, size int, err error) {
+	page, size = 1, defaultPageSize
+	if raw := c.Query("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			return 0, 0, errors.New("page must be a positive number")
+		}
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		if size, err = strconv.Atoi(raw); err != nil || size < 1 {
+			return 0, 0, errors.New("page_size must be a positive number")
+		}
+		size = min(size, maxPageSize)
+	}
+	return page, size, nil
+}
+
+// GetAuditEvents pages through the audit trail, newest first. Filter by entity
+// with ?entity_type= (table name, e.g. employees) and ?entity_id=, by actor
+// with ?actor_type= and ?actor_id=, or by ?action=, ?request_id=, ?from= and ?to=.
+func GetAuditEvents(c *gin.Context) {
+	page, size, err := pagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Model(&models.AuditEvent{})
+	filters := map[string]string{
+		"entity_type": "entity_type = ?",
+		"entity_id":   "entity_id = ?",
+		"actor_type":  "actor_type = ?",
+		"actor_id":    "actor_id = ?",
+		"action":      "action = ?",
+		"request_id":  "request_id = ?",
+		"from":        "created_at >= ?",
+		"to":          "created_at < ?",
+	}
+	for param, condition := range filters {
+		if value := c.Query(param); value != "" {
+			query = query.Where(condition, value)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var events []models.AuditEvent
+	err = query.Order("created_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(&events).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      events,
+		"count":     len(events),
+		"total":     total,
+		"page":      page,
+		"page_size": size,
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateAuthConfig checks that a strong enough JWT_SECRET (and
+// DEVICE_SIGNING_SECRET, when set) is configured and loads the password policy
+func ValidateAuthConfig() error {
+	if len(config.String("JWT_SECRET", "")) < auth.MinSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d characters", auth.MinSecretLength)
+	}
+	if secret := config.String("DEVICE_SIGNING_SECRET", ""); secret != "" && len(secret) < auth.MinSecretLength {
+		return fmt.Errorf("DEVICE_SIGNING_SECRET must be at least %d characters", auth.MinSecretLength)
+	}
+	policy, err := auth.NewPasswordPolicy(config.Int("PASSWORD_MIN_LENGTH", 8), config.String("PASSWORD_BLOCKLIST_FILE", ""))
+	if err != nil {
+		return fmt.Errorf("loading password blocklist: %w", err)
+	}
+	passwordPolicy = policy
+	return nil
+}
+
+type loginInput struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// issueToken signs an access token for user valid for JWT_TTL_MINUTES
+func issueToken(user models.User, amr []string) (string, time.Time, error) {
+	return signUserToken(user, amr, "", time.Duration(config.Int("JWT_TTL_MINUTES", 60))*time.Minute)
+}
+
+// signUserToken signs a token for user with the given scope and lifetime
+func signUserToken(user models.User, amr []string, scope string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(ttl)
+	token, err := auth.SignToken(auth.Claims{
+		Subject:   user.ID,
+		Role:      user.Role,
+		AMR:       amr,
+		Scope:     scope,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	}, config.String("JWT_SECRET", ""))
+	return token, expires, err
+}
+
+// respondLoggedIn answers a successful password check. Users with 2FA get a
+// short-lived token to present with their code at /auth/2fa/verify, and
+// privileged users who must use 2FA but have not enrolled get a token that
+// only allows enrolling; everyone else gets an access token.
+func respondLoggedIn(c *gin.Context, user models.User) {
+	amr := []string{"pwd"}
+	switch {
+	case user.TOTPEnabled:
+		token, expires, err := signUserToken(user, amr, auth.ScopeMFA, mfaTokenTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    token,
+			"expires_at":   expires,
+		})
+	case middleware.MFARequired(user):
+		token, expires, err := signUserToken(user, amr, auth.ScopeMFASetup, mfaSetupTokenTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_setup_required": true,
+			"token":              token,
+			"token_type":         "Bearer",
+			"expires_at":         expires,
+		})
+	default:
+		respondAccessToken(c, user, amr)
+	}
+}
+
+// respondAccessToken issues a full access token for user
+func respondAccessToken(c *gin.Context, user models.User, amr []string) {
+	token, expires, err := issueToken(user, amr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": expires,
+		"data":       user,
+	})
+}
+
+// Login exchanges an email and password for an access token. Repeated
+// failures lock the account for a growing period.
+func Login(c *gin.Context) {
+	var input loginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	found := database.DB.Where("lower(email) = ?", strings.ToLower(strings.TrimSpace(input.Email))).First(&user).Error == nil
+	if found && user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		respondLocked(c, *user.LockedUntil)
+		return
+	}
+
+	var hash *string
+	if found {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, input.Password) {
+		if found {
+			if err := recordFailedLogin(database.DB.WithContext(c.Request.Context()), &user); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+	// With 2FA the failure count is only cleared once the second factor passes
+	if !user.TOTPEnabled {
+		if err := recordSuccessfulLogin(database.DB.WithContext(c.Request.Context()), &user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	respondLoggedIn(c, user)
+}
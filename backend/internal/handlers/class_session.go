@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// absenceQuery totals each enrollment's attendance over the sessions held so
+// far. Excused absences are counted but do not count against the student.
+const absenceQuery = `
+SELECT e.student_id, st.student_code, st.first_name, st.last_name,
+	e.course_id, c.code AS course_code, c.title AS course_title, c.max_absences,
+	e.semester_id,
+	COUNT(cs.id) AS sessions,
+	COUNT(sa.id) FILTER (WHERE sa.status = 'present') AS present,
+	COUNT(sa.id) FILTER (WHERE sa.status = 'late') AS late,
+	COUNT(sa.id) FILTER (WHERE sa.status = 'absent') AS absent,
+	COUNT(sa.id) FILTER (WHERE sa.status = 'excused') AS excused
+FROM enrollments e
+JOIN students st ON st.id = e.student_id
+JOIN courses c ON c.id = e.course_id
+LEFT JOIN class_sessions cs ON cs.course_id = e.course_id AND cs.semester_id = e.semester_id
+	AND cs.deleted_at IS NULL AND cs.date <= CURRENT_DATE
+LEFT JOIN student_attendances sa ON sa.class_session_id = cs.id AND sa.student_id = e.student_id
+WHERE e.status IN ('enrolled', 'completed') AND %s
+GROUP BY e.student_id, st.student_code, st.first_name, st.last_name,
+	e.course_id, c.code, c.title, c.max_absences, e.semester_id
+ORDER BY c.code, st.student_code`
+
+// AbsenceSummary is a student's attendance record in one course
+type AbsenceSummary struct {
+	StudentID   uint    `json:"student_id"`
+	StudentCode string  `json:"student_code"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	CourseID    uint    `json:"course_id"`
+	CourseCode  string  `json:"course_code"`
+	CourseTitle string  `json:"course_title"`
+	SemesterID  uint    `json:"semester_id"`
+	Sessions    int     `json:"sessions"`
+	Present     int     `json:"present"`
+	Late        int     `json:"late"`
+	Absent      int     `json:"absent"`
+	Excused     int     `json:"excused"`
+	AbsenceRate float64 `json:"absence_rate"`
+	MaxAbsences *int    `json:"max_absences"`
+	MaxRate     float64 `json:"max_absence_rate"`
+	Exceeded    bool    `json:"exceeded"`
+}
+
+// evaluate fills in the absence rate and whether the student is over the
+// course's max_absences, or over ABSENCE_MAX_RATE of the sessions held
+func (s *AbsenceSummary) evaluate(maxRate float64) {
+	s.MaxRate = maxRate
+	if s.Sessions > 0 {
+		s.AbsenceRate = math.Round(float64(s.Absent)/float64(s.Sessions)*1000) / 1000
+	}
+	if s.MaxAbsences != nil {
+		s.Exceeded = s.Absent > *s.MaxAbsences
+	} else {
+		s.Exceeded = s.Sessions > 0 && float64(s.Absent)/float64(s.Sessions) > maxRate
+	}
+}
+
+func absenceSummaries(condition string, args ...any) ([]AbsenceSummary, error) {
+	summaries := []AbsenceSummary{}
+	if err := database.DB.Raw(fmt.Sprintf(absenceQuery, condition), args...).Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	maxRate := config.Float("ABSENCE_MAX_RATE", 0.2)
+	for i := range summaries {
+		summaries[i].evaluate(maxRate)
+	}
+	return summaries, nil
+}
+
+type classSessionInput struct {
+	CourseID   uint      `json:"course_id" binding:"required"`
+	SemesterID uint      `json:"semester_id" binding:"required"`
+	Date       time.Time `json:"date" binding:"required"`
+	StartTime  *string   `json:"start_time"`
+	Room       *string   `json:"room"`
+	LecturerID *uint     `json:"lecturer_id"`
+	Topic      *string   `json:"topic"`
+}
+
+func (in classSessionInput) validate() error {
+	var course models.Course
+	if err := database.DB.First(&course, in.CourseID).Error; err != nil {
+		return errors.New("course not found")
+	}
+	var semester models.Semester
+	if err := database.DB.First(&semester, in.SemesterID).Error; err != nil {
+		return errors.New("semester not found")
+	}
+	date := truncateToDate(in.Date)
+	if date.Before(semester.StartDate) || date.After(semester.EndDate) {
+		return errors.New("date is outside the semester")
+	}
+	if in.StartTime != nil {
+		if _, err := time.Parse("15:04", *in.StartTime); err != nil {
+			return errors.New("start_time must be HH:MM")
+		}
+	}
+	if in.LecturerID != nil {
+		var lecturer models.Employee
+		if err := database.DB.First(&lecturer, *in.LecturerID).Error; err != nil {
+			return errors.New("lecturer not found")
+		}
+		if lecturer.Status != models.EmployeeStatusActive {
+			return errors.New("lecturer is not active")
+		}
+	}
+	return nil
+}
+
+func (in classSessionInput) apply(session *models.ClassSession) {
+	session.CourseID = in.CourseID
+	session.SemesterID = in.SemesterID
+	session.Date = truncateToDate(in.Date)
+	session.StartTime = in.StartTime
+	session.Room = in.Room
+	session.LecturerID = in.LecturerID
+	session.Topic = in.Topic
+}
+
+// GetClassSessions lists class sessions filtered by ?course_id=,
+// ?semester_id=, ?lecturer_id=, ?from= and ?to=
+func GetClassSessions(c *gin.Context) {
+	query := database.DB.Preload("Course").Preload("Lecturer").Order("date, start_time")
+	for param, condition := range map[string]string{
+		"course_id":   "course_id = ?",
+		"semester_id": "semester_id = ?",
+		"lecturer_id": "lecturer_id = ?",
+		"from":        "date >= ?",
+		"to":          "date <= ?",
+	} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(condition, value)
+		}
+	}
+
+	var sessions []models.ClassSession
+	if err := query.Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  sessions,
+		"count": len(sessions),
+	})
+}
+
+// GetClassSession retrieves a single class session
+func GetClassSession(c *gin.Context) {
+	id := c.Param("id")
+	var session models.ClassSession
+
+	if err := database.DB.Preload("Course").Preload("Semester").Preload("Lecturer").First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": session})
+}
+
+// CreateClassSession schedules a class session of a course
+func CreateClassSession(c *gin.Context) {
+	var input classSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session models.ClassSession
+	input.apply(&session)
+	if err := database.DB.WithContext(c.Request.Context()).Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": session})
+}
+
+// UpdateClassSession updates an existing class session
+func UpdateClassSession(c *gin.Context) {
+	id := c.Param("id")
+	var session models.ClassSession
+
+	if err := database.DB.First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class session not found"})
+		return
+	}
+
+	var input classSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.CourseID != session.CourseID || input.SemesterID != session.SemesterID {
+		var marked int64
+		database.DB.Model(&models.StudentAttendance{}).Where("class_session_id = ?", session.ID).Count(&marked)
+		if marked > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot move a session that already has attendance"})
+			return
+		}
+	}
+
+	input.apply(&session)
+	if err := database.DB.WithContext(c.Request.Context()).Save(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": session})
+}
+
+// DeleteClassSession cancels a class session; its attendance no longer counts
+func DeleteClassSession(c *gin.Context) {
+	id := c.Param("id")
+	var session models.ClassSession
+
+	if err := database.DB.First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class session not found"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class session deleted successfully"})
+}
+
+type rosterEntry struct {
+	StudentID   uint                            `json:"student_id"`
+	StudentCode string                          `json:"student_code"`
+	FirstName   string                          `json:"first_name"`
+	LastName    string                          `json:"last_name"`
+	Status      *models.StudentAttendanceStatus `json:"status"`
+	Note        *string                         `json:"note"`
+}
+
+// GetSessionAttendance returns the session roster: every enrolled student with
+// their attendance status, null when not yet marked
+func GetSessionAttendance(c *gin.Context) {
+	id := c.Param("id")
+	var session models.ClassSession
+
+	if err := database.DB.First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class session not found"})
+		return
+	}
+	if !canTakeAttendance(c, session) {
+		return
+	}
+
+	roster := []rosterEntry{}
+	err := database.DB.Table("enrollments e").
+		Select("st.id AS student_id, st.student_code, st.first_name, st.last_name, sa.status, sa.note").
+		Joins("JOIN students st ON st.id = e.student_id").
+		Joins("LEFT JOIN student_attendances sa ON sa.student_id = e.student_id AND sa.class_session_id = ?", session.ID).
+		Where("e.course_id = ? AND e.semester_id = ? AND e.status IN ?", session.CourseID, session.SemesterID,
+			[]models.EnrollmentStatus{models.EnrollmentStatusEnrolled, models.EnrollmentStatusCompleted}).
+		Order("st.last_name, st.first_name").
+		Scan(&roster).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  roster,
+		"count": len(roster),
+	})
+}
+
+type attendanceMark struct {
+	StudentID uint                           `json:"student_id" binding:"required"`
+	Status    models.StudentAttendanceStatus `json:"status" binding:"required,oneof=present late absent excused"`
+	Note      *string                        `json:"note"`
+}
+
+type markAttendanceInput struct {
+	// DefaultStatus applies to every enrolled student not listed in Records
+	DefaultStatus *models.StudentAttendanceStatus `json:"default_status" binding:"omitempty,oneof=present late absent excused"`
+	Records       []attendanceMark                `json:"records" binding:"dive"`
+}
+
+// canTakeAttendance reports whether the signed-in user may see and mark the
+// attendance of session: admins, and the lecturer teaching it
+func canTakeAttendance(c *gin.Context, session models.ClassSession) bool {
+	user := middleware.CurrentUser(c)
+	if user.Role == models.UserRoleAdmin {
+		return true
+	}
+	if user.EmployeeID != nil && session.LecturerID != nil && *user.EmployeeID == *session.LecturerID {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Only the session's lecturer can take its attendance"})
+	return false
+}
+
+// MarkSessionAttendance marks attendance for a whole session at once. Listed
+// records are upserted; with default_status every other enrolled student gets
+// that status. The response warns about students now over their absence limit.
+func MarkSessionAttendance(c *gin.Context) {
+	id := c.Param("id")
+	var session models.ClassSession
+
+	if err := database.DB.First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class session not found"})
+		return
+	}
+	if !canTakeAttendance(c, session) {
+		return
+	}
+
+	var input markAttendanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Records) == 0 && input.DefaultStatus == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide records or default_status"})
+		return
+	}
+
+	var enrolled []uint
+	err := database.DB.Model(&models.Enrollment{}).
+		Where("course_id = ? AND semester_id = ? AND status IN ?", session.CourseID, session.SemesterID,
+			[]models.EnrollmentStatus{models.EnrollmentStatusEnrolled, models.EnrollmentStatusCompleted}).
+		Pluck("student_id", &enrolled).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	marks := make(map[uint]attendanceMark, len(enrolled))
+	for _, record := range input.Records {
+		if !containsID(enrolled, record.StudentID) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "Student is not enrolled in this course",
+				"student_id": record.StudentID,
+			})
+			return
+		}
+		marks[record.StudentID] = record
+	}
+	if input.DefaultStatus != nil {
+		for _, studentID := range enrolled {
+			if _, ok := marks[studentID]; !ok {
+				marks[studentID] = attendanceMark{StudentID: studentID, Status: *input.DefaultStatus}
+			}
+		}
+	}
+
+	rows := make([]models.StudentAttendance, 0, len(marks))
+	if len(marks) == 0 {
+		// default_status on a session nobody is enrolled in
+		c.JSON(http.StatusOK, gin.H{
+			"data":     rows,
+			"count":    0,
+			"warnings": []AbsenceSummary{},
+		})
+		return
+	}
+	markedBy := middleware.CurrentUser(c).EmployeeID
+	for _, mark := range marks {
+		rows = append(rows, models.StudentAttendance{
+			ClassSessionID: session.ID,
+			StudentID:      mark.StudentID,
+			Status:         mark.Status,
+			Note:           mark.Note,
+			MarkedByID:     markedBy,
+		})
+	}
+
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "class_session_id"}, {Name: "student_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "note", "marked_by_id", "updated_at"}),
+		}).Create(&rows).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summaries, err := absenceSummaries("e.course_id = ? AND e.semester_id = ?", session.CourseID, session.SemesterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	warnings := []AbsenceSummary{}
+	for _, summary := range summaries {
+		if summary.Exceeded {
+			warnings = append(warnings, summary)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     rows,
+		"count":    len(rows),
+		"warnings": warnings,
+	})
+}
+
+// GetStudentAbsences returns a student's attendance in each course, flagging
+// courses where the absence limit is exceeded, optionally for one ?semester_id=
+func GetStudentAbsences(c *gin.Context) {
+	id := c.Param("id")
+	var student models.Student
+
+	if err := database.DB.First(&student, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	condition, args := "e.student_id = ?", []any{student.ID}
+	if semesterID := c.Query("semester_id"); semesterID != "" {
+		condition += " AND e.semester_id = ?"
+		args = append(args, semesterID)
+	}
+	respondAbsences(c, condition, args)
+}
+
+// GetCourseAbsences returns the attendance of every student in a course,
+// optionally for one ?semester_id=; ?exceeded=true keeps only the warnings
+func GetCourseAbsences(c *gin.Context) {
+	id := c.Param("id")
+	var course models.Course
+
+	if err := database.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	condition, args := "e.course_id = ?", []any{course.ID}
+	if semesterID := c.Query("semester_id"); semesterID != "" {
+		condition += " AND e.semester_id = ?"
+		args = append(args, semesterID)
+	}
+	respondAbsences(c, condition, args)
+}
+
+func respondAbsences(c *gin.Context, condition string, args []any) {
+	summaries, err := absenceSummaries(condition, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("exceeded") == "true" {
+		exceeded := []AbsenceSummary{}
+		for _, summary := range summaries {
+			if summary.Exceeded {
+				exceeded = append(exceeded, summary)
+			}
+		}
+		summaries = exceeded
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  summaries,
+		"count": len(summaries),
+	})
+}
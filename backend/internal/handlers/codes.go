@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-backend/internal/codegen"
+	"project-backend/internal/config"
+	"project-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errCodeTaken = errors.New("code is already in use")
+
+var errUnknownDepartment = errors.New("department not found")
+
+const (
+	defaultEmployeeCodePattern = "EMP-{dept}-{yyyy}-{seq:5}"
+	defaultStudentCodePattern  = "SV{seq:3}"
+)
+
+// ValidateCodePatterns checks the configured EmployeeID and StudentCode patterns
+func ValidateCodePatterns() error {
+	if err := codegen.Validate(config.String("EMPLOYEE_CODE_PATTERN", defaultEmployeeCodePattern)); err != nil {
+		return err
+	}
+	return codegen.Validate(config.String("STUDENT_CODE_PATTERN", defaultStudentCodePattern))
+}
+
+// employeeCodeTaken reports whether an EmployeeID is used by another employee,
+// including soft deleted ones since the unique index still covers them
+func employeeCodeTaken(tx *gorm.DB, code string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&models.Employee{}).
+		Where("employee_id = ? AND id <> ?", code, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// studentCodeTaken reports whether a StudentCode is used by another student
+func studentCodeTaken(tx *gorm.DB, code string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&models.Student{}).
+		Where("student_code = ? AND id <> ?", code, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// departmentCode returns the value used for {dept} in employee code patterns
+func departmentCode(tx *gorm.DB, departmentID *uint) (string, error) {
+	if departmentID == nil {
+		return "NA", nil
+	}
+	var department models.Department
+	if err := tx.First(&department, *departmentID).Error; err != nil {
+		return "", fmt.Errorf("%w: %d", errUnknownDepartment, *departmentID)
+	}
+	if department.Code != nil && *department.Code != "" {
+		return strings.ToUpper(*department.Code), nil
+	}
+	return fmt.Sprintf("D%d", department.ID), nil
+}
+
+// generateEmployeeCode draws the next EmployeeID from EMPLOYEE_CODE_PATTERN
+func generateEmployeeCode(tx *gorm.DB, counter codegen.Counter, employee *models.Employee) (string, error) {
+	dept, err := departmentCode(tx, employee.DepartmentID)
+	if err != nil {
+		return "", err
+	}
+	joined := employee.JoinDate
+	if joined.IsZero() {
+		joined = time.Now()
+	}
+
+	pattern := config.String("EMPLOYEE_CODE_PATTERN", defaultEmployeeCodePattern)
+	return codegen.Generate(counter, pattern, codegen.Vars{Dept: dept, Time: joined}, func(code string) (bool, error) {
+		return employeeCodeTaken(tx, code, 0)
+	})
+}
+
+// generateStudentCode draws the next StudentCode from STUDENT_CODE_PATTERN
+func generateStudentCode(tx *gorm.DB, counter codegen.Counter) (string, error) {
+	pattern := config.String("STUDENT_CODE_PATTERN", defaultStudentCodePattern)
+	return codegen.Generate(counter, pattern, codegen.Vars{Time: time.Now()}, func(code string) (bool, error) {
+		return studentCodeTaken(tx, code, 0)
+	})
+}
+
+// assignEmployeeCode fills EmployeeID from counter when the client omitted it
+// and checks a client supplied one for uniqueness
+func assignEmployeeCode(tx *gorm.DB, counter codegen.Counter, employee *models.Employee) error {
+	if employee.EmployeeID != nil && *employee.EmployeeID != "" {
+		taken, err := employeeCodeTaken(tx, *employee.EmployeeID, employee.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("%w: employee_id %s", errCodeTaken, *employee.EmployeeID)
+		}
+		return nil
+	}
+
+	code, err := generateEmployeeCode(tx, counter, employee)
+	if err != nil {
+		return err
+	}
+	employee.EmployeeID = &code
+	return nil
+}
+
+// assignStudentCode fills StudentCode from counter when the client omitted it
+// and checks a client supplied one for uniqueness
+func assignStudentCode(tx *gorm.DB, counter codegen.Counter, student *models.Student) error {
+	if student.StudentCode != "" {
+		taken, err := studentCodeTaken(tx, student.StudentCode, student.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("%w: student_code %s", errCodeTaken, student.StudentCode)
+		}
+		return nil
+	}
+
+	code, err := generateStudentCode(tx, counter)
+	if err != nil {
+		return err
+	}
+	student.StudentCode = code
+	return nil
+}
+
+// respondCodeError maps code assignment errors to HTTP responses
+func respondCodeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errCodeTaken), errors.Is(err, errEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, errUnknownDepartment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errCorrectionReviewed = errors.New("attendance correction has already been reviewed")
+	errCheckInOffDate     = errors.New("check_in must fall on the correction date")
+	errCheckOutOffDate    = errors.New("check_out must fall on the correction date")
+	errCheckOutOrder      = errors.New("check_out must be after check_in")
+)
+
+// validateCorrectionTimes checks the check-in/out pair a correction would
+// leave on the record: both on the correction's day (server time, like the
+// terminals record it) and check-out after check-in
+func validateCorrectionTimes(date time.Time, checkIn, checkOut *time.Time) error {
+	sameDay := func(t time.Time) bool {
+		y, m, d := t.Local().Date()
+		dy, dm, dd := date.Date()
+		return y == dy && m == dm && d == dd
+	}
+	if checkIn != nil && !sameDay(*checkIn) {
+		return errCheckInOffDate
+	}
+	if checkOut != nil && !sameDay(*checkOut) {
+		return errCheckOutOffDate
+	}
+	if checkIn != nil && checkOut != nil && !checkOut.After(*checkIn) {
+		return errCheckOutOrder
+	}
+	return nil
+}
+
+// mergeCorrectionTimes overlays the proposed times on the record's current ones
+func mergeCorrectionTimes(correction models.AttendanceCorrection, record models.AttendanceRecord) (checkIn, checkOut *time.Time) {
+	checkIn, checkOut = record.CheckIn, record.CheckOut
+	if correction.ProposedCheckIn != nil {
+		checkIn = correction.ProposedCheckIn
+	}
+	if correction.ProposedCheckOut != nil {
+		checkOut = correction.ProposedCheckOut
+	}
+	return checkIn, checkOut
+}
+
+type correctionAttachmentInput struct {
+	FileName string `json:"file_name" binding:"required"`
+	URL      string `json:"url" binding:"required,url"`
+}
+
+type createCorrectionInput struct {
+	EmployeeID       *uint                       `json:"employee_id"`
+	Date             time.Time                   `json:"date" binding:"required"`
+	ProposedCheckIn  *time.Time                  `json:"proposed_check_in"`
+	ProposedCheckOut *time.Time                  `json:"proposed_check_out"`
+	Reason           string                      `json:"reason" binding:"required"`
+	Attachments      []correctionAttachmentInput `json:"attachments" binding:"dive"`
+}
+
+// CreateAttendanceCorrection lets an employee propose new check-in/out times
+// for a day. Admin and HR may file on behalf of any employee.
+func CreateAttendanceCorrection(c *gin.Context) {
+	var input createCorrectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.ProposedCheckIn == nil && input.ProposedCheckOut == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proposed_check_in or proposed_check_out is required"})
+		return
+	}
+	employeeID, ok := requestEmployeeID(c, input.EmployeeID)
+	if !ok {
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.First(&employee, employeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	correction := models.AttendanceCorrection{
+		EmployeeID:       employee.ID,
+		Date:             truncateToDate(input.Date),
+		ProposedCheckIn:  input.ProposedCheckIn,
+		ProposedCheckOut: input.ProposedCheckOut,
+		Reason:           input.Reason,
+		Status:           models.CorrectionStatusPending,
+	}
+	for _, a := range input.Attachments {
+		correction.Attachments = append(correction.Attachments, models.AttendanceCorrectionAttachment{
+			FileName: a.FileName,
+			URL:      a.URL,
+		})
+	}
+
+	// Link the existing record when there is one; a missed check-in has none yet
+	var record models.AttendanceRecord
+	err := database.DB.Where("employee_id = ? AND date = ?", employee.ID, correction.Date).First(&record).Error
+	switch {
+	case err == nil:
+		correction.AttendanceRecordID = &record.ID
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if correction.ProposedCheckIn == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "proposed_check_in is required when there is no attendance record for the day"})
+			return
+		}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A lone proposed time still has to fit the record's other one
+	checkIn, checkOut := mergeCorrectionTimes(correction, record)
+	if err := validateCorrectionTimes(correction.Date, checkIn, checkOut); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Create(&correction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": correction})
+}
+
+// GetAttendanceCorrections retrieves correction requests filtered by employee
+// and status. Managers see their departments' requests, other employees their own.
+func GetAttendanceCorrections(c *gin.Context) {
+	query, ok := visibleRequests(c, database.DB.Preload("Employee").Preload("Approver").Preload("Attachments").Order("created_at DESC"))
+	if !ok {
+		return
+	}
+
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var corrections []models.AttendanceCorrection
+	if err := query.Find(&corrections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  corrections,
+		"count": len(corrections),
+	})
+}
+
+// GetAttendanceCorrection retrieves a single correction request by ID
+func GetAttendanceCorrection(c *gin.Context) {
+	id := c.Param("id")
+	var correction models.AttendanceCorrection
+
+	query, ok := visibleRequests(c, database.DB.Preload("Employee").Preload("Approver").Preload("Attachments"))
+	if !ok {
+		return
+	}
+	if err := query.First(&correction, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance correction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction})
+}
+
+// ApproveAttendanceCorrection applies a correction to the attendance record,
+// archiving the original values and recomputing the day's late/overtime figures
+func ApproveAttendanceCorrection(c *gin.Context) {
+	correction, approverID, input, ok := loadCorrectionForReview(c)
+	if !ok {
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.Unscoped().Preload("Shift").First(&employee, correction.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var record models.AttendanceRecord
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// A concurrent review may have decided since the correction was loaded
+		var current models.AttendanceCorrection
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, correction.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != models.CorrectionStatusPending {
+			return errCorrectionReviewed
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("employee_id = ? AND date = ?", correction.EmployeeID, correction.Date).
+			First(&record).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			record = models.AttendanceRecord{
+				EmployeeID: correction.EmployeeID,
+				Date:       correction.Date,
+				Status:     models.AttendanceStatusPresent,
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			history := models.AttendanceRecordHistory{
+				AttendanceRecordID: record.ID,
+				CorrectionID:       &correction.ID,
+				CheckIn:            record.CheckIn,
+				CheckOut:           record.CheckOut,
+				Status:             record.Status,
+				LateMinutes:        record.LateMinutes,
+				OvertimeMinutes:    record.OvertimeMinutes,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+		}
+
+		// The record may have changed since the correction was filed
+		record.CheckIn, record.CheckOut = mergeCorrectionTimes(correction, record)
+		if err := validateCorrectionTimes(correction.Date, record.CheckIn, record.CheckOut); err != nil {
+			return err
+		}
+		if err := tx.Model(&record).Select("check_in", "check_out").Updates(&record).Error; err != nil {
+			return err
+		}
+		if err := recomputeAttendance(tx, employee.Shift, &record); err != nil {
+			return err
+		}
+
+		return markCorrectionReviewed(tx, &correction, approverID, input, models.CorrectionStatusApproved, &record.ID)
+	})
+	if err != nil {
+		respondCorrectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction, "record": record})
+}
+
+// RejectAttendanceCorrection rejects a pending correction, leaving the record untouched
+func RejectAttendanceCorrection(c *gin.Context) {
+	correction, approverID, input, ok := loadCorrectionForReview(c)
+	if !ok {
+		return
+	}
+
+	if err := markCorrectionReviewed(database.DB.WithContext(c.Request.Context()), &correction, approverID, input, models.CorrectionStatusRejected, correction.AttendanceRecordID); err != nil {
+		respondCorrectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction})
+}
+
+// loadCorrectionForReview binds the review input and checks that the
+// correction is pending and the signed-in user manages the employee
+func loadCorrectionForReview(c *gin.Context) (models.AttendanceCorrection, uint, reviewInput, bool) {
+	id := c.Param("id")
+	var correction models.AttendanceCorrection
+	var input reviewInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return correction, 0, input, false
+	}
+	approverID, ok := reviewerID(c)
+	if !ok {
+		return correction, 0, input, false
+	}
+
+	if err := database.DB.Preload("Employee", withDeletedEmployee).First(&correction, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance correction not found"})
+		return correction, 0, input, false
+	}
+	if correction.Status != models.CorrectionStatusPending {
+		respondCorrectionError(c, errCorrectionReviewed)
+		return correction, 0, input, false
+	}
+	if correction.Employee == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Employee no longer exists"})
+		return correction, 0, input, false
+	}
+
+	allowed, err := canApprove(approverID, *correction.Employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return correction, 0, input, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the department manager can review this request"})
+		return correction, 0, input, false
+	}
+
+	return correction, approverID, input, true
+}
+
+// markCorrectionReviewed records the decision on a still pending correction
+func markCorrectionReviewed(tx *gorm.DB, correction *models.AttendanceCorrection, approverID uint, input reviewInput, status models.CorrectionStatus, recordID *uint) error {
+	now := time.Now()
+	correction.Status = status
+	correction.ApproverID = &approverID
+	correction.ReviewedAt = &now
+	correction.ReviewNote = input.Note
+	correction.AttendanceRecordID = recordID
+	result := tx.Model(correction).
+		Where("status = ?", models.CorrectionStatusPending).
+		Select("status", "approver_id", "reviewed_at", "review_note", "attendance_record_id").
+		Updates(correction)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errCorrectionReviewed
+	}
+	return nil
+}
+
+func respondCorrectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errCorrectionReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance correction has already been reviewed"})
+	case errors.Is(err, errCheckInOffDate), errors.Is(err, errCheckOutOffDate), errors.Is(err, errCheckOutOrder):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
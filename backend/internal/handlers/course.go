@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errPrerequisiteCycle    = errors.New("prerequisites would create a cycle")
+	errCourseHasEnrollments = errors.New("course has enrolled or waitlisted students")
+)
+
+// prerequisitesQuery returns every course transitively required by the given
+// courses, including the courses themselves
+const prerequisitesQuery = `
+WITH RECURSIVE required AS (
+	SELECT id FROM courses WHERE id IN ?
+	UNION
+	SELECT cp.prerequisite_id FROM course_prerequisites cp
+	JOIN required r ON cp.course_id = r.id
+)
+SELECT id FROM required`
+
+type courseInput struct {
+	Code            string  `json:"code" binding:"required"`
+	Title           string  `json:"title" binding:"required"`
+	Credits         int     `json:"credits" binding:"required,gt=0"`
+	Major           *string `json:"major"`
+	Description     *string `json:"description"`
+	Capacity        int     `json:"capacity" binding:"required,gt=0"`
+	MaxAbsences     *int    `json:"max_absences" binding:"omitempty,gte=0"`
+	PrerequisiteIDs []uint  `json:"prerequisite_ids"`
+}
+
+func (in courseInput) apply(course *models.Course) {
+	course.Code = in.Code
+	course.Title = in.Title
+	course.Credits = in.Credits
+	course.Major = in.Major
+	course.Description = in.Description
+	course.Capacity = in.Capacity
+	course.MaxAbsences = in.MaxAbsences
+}
+
+// loadPrerequisites fetches the prerequisite courses and rejects unknown ids
+// and sets that would make the course require itself
+func loadPrerequisites(courseID uint, ids []uint) ([]models.Course, error) {
+	prerequisites := []models.Course{}
+	if len(ids) == 0 {
+		return prerequisites, nil
+	}
+	if err := database.DB.Where("id IN ?", ids).Find(&prerequisites).Error; err != nil {
+		return nil, err
+	}
+	if len(prerequisites) != len(uniqueIDs(ids)) {
+		return nil, errors.New("unknown prerequisite course")
+	}
+	if courseID == 0 {
+		return prerequisites, nil
+	}
+
+	var required []uint
+	if err := database.DB.Raw(prerequisitesQuery, ids).Scan(&required).Error; err != nil {
+		return nil, err
+	}
+	if containsID(required, courseID) {
+		return nil, errPrerequisiteCycle
+	}
+	return prerequisites, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	var unique []uint
+	for _, id := range ids {
+		if !containsID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// GetCourses lists the course catalogue, optionally filtered by ?major=
+func GetCourses(c *gin.Context) {
+	query := database.DB.Preload("Prerequisites").Order("code")
+	if major := c.Query("major"); major != "" {
+		query = query.Where("major = ?", major)
+	}
+
+	var courses []models.Course
+	if err := query.Find(&courses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  courses,
+		"count": len(courses),
+	})
+}
+
+// GetCourse retrieves a single course with its prerequisites
+func GetCourse(c *gin.Context) {
+	id := c.Param("id")
+	var course models.Course
+
+	if err := database.DB.Preload("Prerequisites").First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": course})
+}
+
+// CreateCourse adds a course to the catalogue
+func CreateCourse(c *gin.Context) {
+	var input courseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prerequisites, err := loadPrerequisites(0, input.PrerequisiteIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var course models.Course
+	input.apply(&course)
+	course.Prerequisites = prerequisites
+	if err := database.DB.WithContext(c.Request.Context()).Create(&course).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": course})
+}
+
+// UpdateCourse updates a course and replaces its prerequisites. Raising the
+// capacity promotes waitlisted students into the new seats.
+func UpdateCourse(c *gin.Context) {
+	id := c.Param("id")
+	var course models.Course
+
+	if err := database.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	var input courseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prerequisites, err := loadPrerequisites(course.ID, input.PrerequisiteIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.apply(&course)
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Prerequisites").Save(&course).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&course).Association("Prerequisites").Replace(prerequisites); err != nil {
+			return err
+		}
+
+		var semesterIDs []uint
+		err := tx.Model(&models.Enrollment{}).
+			Where("course_id = ? AND status = ?", course.ID, models.EnrollmentStatusWaitlisted).
+			Distinct().Pluck("semester_id", &semesterIDs).Error
+		if err != nil {
+			return err
+		}
+		for _, semesterID := range semesterIDs {
+			if err := fillFromWaitlist(tx, course.ID, semesterID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Prerequisites").First(&course, course.ID)
+
+	c.JSON(http.StatusOK, gin.H{"data": course})
+}
+
+// DeleteCourse removes a course that nobody is enrolled in or waiting for
+func DeleteCourse(c *gin.Context) {
+	id := c.Param("id")
+	var course models.Course
+
+	if err := database.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Enrolling locks the course too, so no student can join until the
+		// delete commits
+		if _, err := lockCourse(tx, course.ID); err != nil {
+			return err
+		}
+		var live int64
+		err := tx.Model(&models.Enrollment{}).
+			Where("course_id = ? AND status IN ?", course.ID, liveEnrollmentStatuses).
+			Count(&live).Error
+		if err != nil {
+			return err
+		}
+		if live > 0 {
+			return errCourseHasEnrollments
+		}
+		return tx.Delete(&course).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+	if errors.Is(err, errCourseHasEnrollments) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Course has enrolled or waitlisted students"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Course deleted successfully"})
+}
+
+// GetSemesters lists semesters, most recent first
+func GetSemesters(c *gin.Context) {
+	var semesters []models.Semester
+	if err := database.DB.Order("start_date DESC").Find(&semesters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  semesters,
+		"count": len(semesters),
+	})
+}
+
+// GetSemester retrieves a single semester by ID
+func GetSemester(c *gin.Context) {
+	id := c.Param("id")
+	var semester models.Semester
+
+	if err := database.DB.First(&semester, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Semester not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": semester})
+}
+
+// CreateSemester creates a new semester
+func CreateSemester(c *gin.Context) {
+	var semester models.Semester
+	if err := c.ShouldBindJSON(&semester); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if semester.EndDate.Before(semester.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Create(&semester).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": semester})
+}
+
+// UpdateSemester updates an existing semester
+func UpdateSemester(c *gin.Context) {
+	id := c.Param("id")
+	var semester models.Semester
+
+	if err := database.DB.First(&semester, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Semester not found"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&semester); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if semester.EndDate.Before(semester.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Save(&semester).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": semester})
+}
+
+// DeleteSemester removes a semester without enrollments
+func DeleteSemester(c *gin.Context) {
+	id := c.Param("id")
+	var semester models.Semester
+
+	if err := database.DB.First(&semester, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Semester not found"})
+		return
+	}
+
+	var enrollments int64
+	database.DB.Model(&models.Enrollment{}).Where("semester_id = ?", semester.ID).Count(&enrollments)
+	if enrollments > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Semester has enrollments"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&semester).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Semester deleted successfully"})
+}
@@ -1,13 +1,110 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"project-backend/internal/database"
 	"project-backend/internal/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	errDepartmentCycle    = errors.New("department cannot be moved under itself or one of its descendants")
+	errDepartmentNotEmpty = errors.New("department still has employees or child departments")
+)
+
+const subtreeQuery = `
+WITH RECURSIVE subtree AS (
+	SELECT id FROM departments WHERE id IN ? AND deleted_at IS NULL
+	UNION
+	SELECT d.id FROM departments d JOIN subtree s ON d.parent_id = s.id WHERE d.deleted_at IS NULL
+)
+SELECT id FROM subtree`
+
+const ancestorsQuery = `
+WITH RECURSIVE ancestors AS (
+	SELECT id, parent_id FROM departments WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT d.id, d.parent_id FROM departments d JOIN ancestors a ON d.id = a.parent_id WHERE d.deleted_at IS NULL
+)
+SELECT id FROM ancestors`
+
+const managedQuery = `
+WITH RECURSIVE managed AS (
+	SELECT id FROM departments WHERE manager_id = ? AND deleted_at IS NULL
+	UNION
+	SELECT d.id FROM departments d JOIN managed m ON d.parent_id = m.id WHERE d.deleted_at IS NULL
+)
+SELECT id FROM managed`
+
+// departmentSubtreeIDs returns the given departments and all their descendants
+func departmentSubtreeIDs(ids ...uint) ([]uint, error) {
+	var result []uint
+	err := database.DB.Raw(subtreeQuery, ids).Scan(&result).Error
+	return result, err
+}
+
+// departmentAncestorIDs returns the department and all departments above it
+func departmentAncestorIDs(id uint) ([]uint, error) {
+	var result []uint
+	err := database.DB.Raw(ancestorsQuery, id).Scan(&result).Error
+	return result, err
+}
+
+// managedDepartmentIDs returns every department an employee can see as a
+// manager: the ones they manage directly and everything below them
+func managedDepartmentIDs(employeeID uint) ([]uint, error) {
+	var result []uint
+	err := database.DB.Raw(managedQuery, employeeID).Scan(&result).Error
+	return result, err
+}
+
+// validateParent refuses a parent that would create a cycle in the hierarchy
+func validateParent(departmentID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	var parent models.Department
+	if err := database.DB.First(&parent, *parentID).Error; err != nil {
+		return errors.New("parent department not found")
+	}
+	if departmentID == 0 {
+		return nil
+	}
+
+	ancestors, err := departmentAncestorIDs(*parentID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ancestors {
+		if id == departmentID {
+			return errDepartmentCycle
+		}
+	}
+	return nil
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
 // GetDepartments retrieves all departments with manager information
 func GetDepartments(c *gin.Context) {
 	var departments []models.Department
@@ -47,6 +144,7 @@ func UpdateDepartment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
 		return
 	}
+	previousManagerID := department.ManagerID
 
 	// Bind updated data
 	if err := c.ShouldBindJSON(&department); err != nil {
@@ -54,9 +152,33 @@ func UpdateDepartment(c *gin.Context) {
 		return
 	}
 
+	// Reject parents that would turn the hierarchy into a cycle
+	if err := validateParent(department.ID, department.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate the manager and keep the manager history in step
+	managerChanged := !sameID(previousManagerID, department.ManagerID)
+	if managerChanged {
+		if err := validateManager(department.ID, department.ParentID, department.ManagerID); err != nil {
+			respondManagerError(c, err)
+			return
+		}
+	}
+
 	// Update department
-	if err := database.DB.Save(&department).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&department).Error; err != nil {
+			return err
+		}
+		if managerChanged {
+			return recordManagerChange(tx, department.ID, department.ManagerID, time.Now(), nil)
+		}
+		return nil
+	})
+	if err != nil {
+		respondManagerError(c, err)
 		return
 	}
 
@@ -74,11 +196,250 @@ func CreateDepartment(c *gin.Context) {
 		return
 	}
 
-	result := database.DB.Create(&department)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := validateParent(0, department.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateManager(0, department.ParentID, department.ManagerID); err != nil {
+		respondManagerError(c, err)
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&department).Error; err != nil {
+			return err
+		}
+		if department.ManagerID != nil {
+			return recordManagerChange(tx, department.ID, department.ManagerID, time.Now(), nil)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"data": department})
 }
+
+// GetDepartmentTree returns all departments nested under their parents
+func GetDepartmentTree(c *gin.Context) {
+	var departments []models.Department
+	if err := database.DB.Preload("Manager").Order("name").Find(&departments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byParent := make(map[uint][]models.Department)
+	known := make(map[uint]bool, len(departments))
+	for _, d := range departments {
+		known[d.ID] = true
+	}
+	var roots []models.Department
+	for _, d := range departments {
+		if d.ParentID == nil || !known[*d.ParentID] {
+			roots = append(roots, d)
+			continue
+		}
+		byParent[*d.ParentID] = append(byParent[*d.ParentID], d)
+	}
+
+	var attach func(nodes []models.Department) []models.Department
+	attach = func(nodes []models.Department) []models.Department {
+		for i := range nodes {
+			nodes[i].Children = attach(byParent[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  attach(roots),
+		"count": len(departments),
+	})
+}
+
+// GetDepartmentDescendants returns every department below the given one
+func GetDepartmentDescendants(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	ids, err := departmentSubtreeIDs(department.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var descendants []models.Department
+	if err := database.DB.Preload("Manager").Where("id IN ? AND id <> ?", ids, department.ID).Find(&descendants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  descendants,
+		"count": len(descendants),
+	})
+}
+
+// GetManagedDepartments returns the departments an employee manages,
+// including all child departments they inherit visibility over
+func GetManagedDepartments(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+	if err := database.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	ids, err := managedDepartmentIDs(employee.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var departments []models.Department
+	if err := database.DB.Where("id IN ?", ids).Find(&departments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  departments,
+		"count": len(departments),
+	})
+}
+
+// DeleteDepartment soft deletes a department. It refuses while employees or
+// child departments remain unless ?reassign_to=<id> names a department to move
+// them to, in which case everything is moved in the same transaction.
+func DeleteDepartment(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	var target *models.Department
+	if reassignTo := c.Query("reassign_to"); reassignTo != "" {
+		target = &models.Department{}
+		if err := database.DB.First(target, reassignTo).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to department not found"})
+			return
+		}
+		subtree, err := departmentSubtreeIDs(department.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if containsID(subtree, target.ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to cannot be the department itself or one of its descendants"})
+			return
+		}
+	}
+
+	var employeeCount, childCount int64
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// The lock holds off new employees and child departments, whose
+		// foreign keys need a share lock on this row, until the delete commits
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&department, department.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Employee{}).Where("department_id = ?", department.ID).Count(&employeeCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Department{}).Where("parent_id = ?", department.ID).Count(&childCount).Error; err != nil {
+			return err
+		}
+
+		if target == nil && (employeeCount > 0 || childCount > 0) {
+			return errDepartmentNotEmpty
+		}
+		if target != nil {
+			if err := tx.Model(&models.Employee{}).Where("department_id = ?", department.ID).
+				Update("department_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Department{}).Where("parent_id = ?", department.ID).
+				Update("parent_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Device{}).Where("department_id = ?", department.ID).
+				Update("department_id", target.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.DepartmentManagerHistory{}).
+			Where("department_id = ? AND effective_to IS NULL", department.ID).
+			Update("effective_to", truncateToDate(time.Now())).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&department).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+	if errors.Is(err, errDepartmentNotEmpty) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Department still has employees or child departments; pass reassign_to to move them",
+			"employees":   employeeCount,
+			"departments": childCount,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "Department deleted successfully"}
+	if target != nil {
+		response["reassigned_to"] = target.ID
+		response["employees_moved"] = employeeCount
+		response["departments_moved"] = childCount
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreDepartment brings back a soft deleted department. Employees that were
+// reassigned stay where they are; a deleted parent is detached.
+func RestoreDepartment(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted department not found"})
+		return
+	}
+
+	updates := map[string]any{"deleted_at": nil}
+	if department.ParentID != nil {
+		var parent models.Department
+		if err := database.DB.First(&parent, *department.ParentID).Error; err != nil {
+			updates["parent_id"] = nil
+		}
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&department).Updates(updates).Error; err != nil {
+			return err
+		}
+		if department.ManagerID != nil {
+			return recordManagerChange(tx, department.ID, department.ManagerID, time.Now(), nil)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Manager").First(&department, department.ID)
+
+	c.JSON(http.StatusOK, gin.H{"data": department})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Manager scope rules, selected with DEPARTMENT_MANAGER_SCOPE
+const (
+	managerScopeAny        = "any"        // any active employee
+	managerScopeDepartment = "department" // must belong to the department itself
+	managerScopeSubtree    = "subtree"    // must belong to the department or a descendant
+)
+
+var (
+	errManagerNotFound    = errors.New("manager must be an existing employee")
+	errManagerNotActive   = errors.New("manager must be an active employee")
+	errManagerOutOfScope  = errors.New("manager must belong to the department")
+	errEffectiveDateOrder = errors.New("effective_date is before the current manager's start date")
+	errEffectiveDateAhead = errors.New("effective_date cannot be in the future")
+)
+
+// validateManager checks that managerID is an active employee allowed to run
+// the department under the configured scope rule. For a department that does
+// not exist yet (departmentID 0) membership is checked against the parent.
+func validateManager(departmentID uint, parentID *uint, managerID *uint) error {
+	if managerID == nil {
+		return nil
+	}
+
+	var manager models.Employee
+	if err := database.DB.First(&manager, *managerID).Error; err != nil {
+		return errManagerNotFound
+	}
+	if manager.Status != models.EmployeeStatusActive {
+		return errManagerNotActive
+	}
+
+	scopeRoot := departmentID
+	if scopeRoot == 0 {
+		if parentID == nil {
+			// A new root department has no members to check against yet
+			return nil
+		}
+		scopeRoot = *parentID
+	}
+
+	switch config.String("DEPARTMENT_MANAGER_SCOPE", managerScopeSubtree) {
+	case managerScopeAny:
+		return nil
+	case managerScopeDepartment:
+		if manager.DepartmentID == nil || *manager.DepartmentID != scopeRoot {
+			return errManagerOutOfScope
+		}
+		return nil
+	default:
+		if manager.DepartmentID == nil {
+			return errManagerOutOfScope
+		}
+		scope, err := departmentSubtreeIDs(scopeRoot)
+		if err != nil {
+			return err
+		}
+		if !containsID(scope, *manager.DepartmentID) {
+			return errManagerOutOfScope
+		}
+		return nil
+	}
+}
+
+// recordManagerChange closes the open history entry of a department and opens
+// a new one for managerID starting at effective
+func recordManagerChange(tx *gorm.DB, departmentID uint, managerID *uint, effective time.Time, reason *string) error {
+	effective = truncateToDate(effective)
+
+	var current models.DepartmentManagerHistory
+	err := tx.Where("department_id = ? AND effective_to IS NULL", departmentID).First(&current).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return err
+	default:
+		if effective.Before(current.EffectiveFrom) {
+			return errEffectiveDateOrder
+		}
+		if err := tx.Model(&current).Update("effective_to", effective).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&models.DepartmentManagerHistory{
+		DepartmentID:  departmentID,
+		ManagerID:     managerID,
+		EffectiveFrom: effective,
+		Reason:        reason,
+	}).Error
+}
+
+// respondManagerError maps manager validation errors to HTTP responses
+func respondManagerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errManagerNotFound), errors.Is(err, errManagerNotActive),
+		errors.Is(err, errManagerOutOfScope), errors.Is(err, errEffectiveDateOrder),
+		errors.Is(err, errEffectiveDateAhead):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+type setManagerInput struct {
+	ManagerID     *uint      `json:"manager_id"`
+	EffectiveDate *time.Time `json:"effective_date"`
+	Reason        *string    `json:"reason"`
+}
+
+// SetDepartmentManager assigns (or clears) the manager of a department and
+// records the change in the manager history
+func SetDepartmentManager(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	var input setManagerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateManager(department.ID, department.ParentID, input.ManagerID); err != nil {
+		respondManagerError(c, err)
+		return
+	}
+
+	effective := time.Now()
+	if input.EffectiveDate != nil {
+		effective = *input.EffectiveDate
+		// manager_id changes right away, so the history cannot start later
+		if truncateToDate(effective).After(truncateToDate(time.Now().In(effective.Location()))) {
+			respondManagerError(c, errEffectiveDateAhead)
+			return
+		}
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Serialize concurrent assignments so the history keeps one open entry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&department, department.ID).Error; err != nil {
+			return err
+		}
+		if err := recordManagerChange(tx, department.ID, input.ManagerID, effective, input.Reason); err != nil {
+			return err
+		}
+		return tx.Model(&department).Update("manager_id", input.ManagerID).Error
+	})
+	if err != nil {
+		respondManagerError(c, err)
+		return
+	}
+
+	database.DB.Preload("Manager").First(&department, department.ID)
+
+	c.JSON(http.StatusOK, gin.H{"data": department})
+}
+
+// GetDepartmentManagerHistory lists the managers of a department over time
+func GetDepartmentManagerHistory(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	var history []models.DepartmentManagerHistory
+	err := database.DB.Preload("Manager").
+		Where("department_id = ?", department.ID).
+		Order("effective_from DESC, id DESC").
+		Find(&history).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  history,
+		"count": len(history),
+	})
+}
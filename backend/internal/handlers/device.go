@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type deviceInput struct {
+	Name         string               `json:"name" binding:"required"`
+	Location     *string              `json:"location"`
+	DepartmentID *uint                `json:"department_id"`
+	Status       *models.DeviceStatus `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// GetDevices retrieves all registered attendance devices
+func GetDevices(c *gin.Context) {
+	var devices []models.Device
+	query := database.DB.Preload("Department")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  devices,
+		"count": len(devices),
+	})
+}
+
+// GetDevice retrieves a single device by ID
+func GetDevice(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	result := database.DB.Preload("Department").First(&device, id)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device})
+}
+
+// deviceCredentials is the response carrying a device's new API key and, when
+// request signing is configured, the secret it signs requests with. It is
+// never stored for Idempotency-Key replays.
+func deviceCredentials(c *gin.Context, device models.Device, key string) gin.H {
+	middleware.SkipIdempotency(c)
+	response := gin.H{"data": device, "api_key": key}
+	if secret := config.String("DEVICE_SIGNING_SECRET", ""); secret != "" {
+		response["signing_secret"] = auth.DeviceSigningKey(secret, device.KeyPrefix)
+	}
+	return response
+}
+
+// CreateDevice registers a device and returns its API key. The key is only
+// shown once; afterwards only its hash is stored.
+func CreateDevice(c *gin.Context) {
+	var input deviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefix, key, err := auth.GenerateDeviceKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	device := models.Device{
+		Name:         input.Name,
+		Location:     input.Location,
+		DepartmentID: input.DepartmentID,
+		Status:       models.DeviceStatusActive,
+		KeyPrefix:    prefix,
+		KeyHash:      auth.HashDeviceKey(key),
+	}
+	if input.Status != nil {
+		device.Status = *input.Status
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Create(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, deviceCredentials(c, device, key))
+}
+
+// UpdateDevice updates a device's name, location, department scope or status
+func UpdateDevice(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	if err := database.DB.First(&device, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+	if device.Status == models.DeviceStatusRevoked {
+		c.JSON(http.StatusConflict, gin.H{"error": "Device has been revoked"})
+		return
+	}
+
+	var input deviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device.Name = input.Name
+	device.Location = input.Location
+	device.DepartmentID = input.DepartmentID
+	if input.Status != nil {
+		device.Status = *input.Status
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Save(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device})
+}
+
+// RotateDeviceKey issues a new API key for a device, invalidating the old one
+func RotateDeviceKey(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	if err := database.DB.First(&device, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+	if device.Status == models.DeviceStatusRevoked {
+		c.JSON(http.StatusConflict, gin.H{"error": "Device has been revoked"})
+		return
+	}
+
+	prefix, key, err := auth.GenerateDeviceKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	device.KeyPrefix = prefix
+	device.KeyHash = auth.HashDeviceKey(key)
+	device.KeyRotatedAt = &now
+	if err := database.DB.WithContext(c.Request.Context()).Save(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deviceCredentials(c, device, key))
+}
+
+// RevokeDevice permanently blocks a device from calling attendance endpoints
+func RevokeDevice(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	if err := database.DB.First(&device, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+
+	device.Status = models.DeviceStatusRevoked
+	if err := database.DB.WithContext(c.Request.Context()).Save(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device})
+}
+
+// DeleteDevice soft deletes a device
+func DeleteDevice(c *gin.Context) {
+	id := c.Param("id")
+	var device models.Device
+
+	result := database.DB.First(&device, id)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
+}
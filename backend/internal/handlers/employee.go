@@ -1,19 +1,97 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"project-backend/internal/codegen"
 	"project-backend/internal/database"
+	"project-backend/internal/filter"
 	"project-backend/internal/models"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetEmployees retrieves all employees with optional department information
+// employeeFilterFields are the fields ?filter= may use on employees;
+// department matches the department code
+var employeeFilterFields = filter.Fields{
+	"employee_id":      {Column: "employees.employee_id"},
+	"code":             {Column: "employees.employee_id"},
+	"first_name":       {Column: "employees.first_name"},
+	"last_name":        {Column: "employees.last_name"},
+	"email":            {Column: "employees.email"},
+	"phone":            {Column: "employees.phone"},
+	"position":         {Column: "employees.position"},
+	"status":           {Column: "employees.status", Type: filter.Enum, Values: []string{"active", "inactive", "suspended", "terminated"}},
+	"department":       {Column: "(SELECT fd.code FROM departments fd WHERE fd.id = employees.department_id)"},
+	"department_name":  {Column: "(SELECT fd.name FROM departments fd WHERE fd.id = employees.department_id)"},
+	"department_id":    {Column: "employees.department_id", Type: filter.Number},
+	"join_date":        {Column: "employees.join_date", Type: filter.Date},
+	"termination_date": {Column: "employees.termination_date", Type: filter.Date},
+	"created_at":       {Column: "employees.created_at", Type: filter.Date},
+}
+
+// employeeFilters builds the ?status=, ?department_id=, ?include_descendants=
+// and ?filter= filters shared by the employee list and export endpoints
+func employeeFilters(c *gin.Context) (func(*gorm.DB) *gorm.DB, error) {
+	condition, args, err := filter.Compile(c.Query("filter"), employeeFilterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var departmentIDs []uint
+	if departmentID := c.Query("department_id"); departmentID != "" {
+		id, err := strconv.ParseUint(departmentID, 10, 64)
+		if err != nil {
+			return nil, errors.New("department_id must be a number")
+		}
+		departmentIDs = []uint{uint(id)}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if status := c.Query("status"); status != "" {
+			db = db.Where("employees.status = ?", status)
+		}
+		if len(departmentIDs) > 0 {
+			if c.Query("include_descendants") == "true" {
+				db = db.Where("employees.department_id IN (?)", database.DB.Raw(subtreeQuery, departmentIDs))
+			} else {
+				db = db.Where("employees.department_id = ?", departmentIDs[0])
+			}
+		}
+		if condition != "" {
+			db = db.Where(condition, args...)
+		}
+		return db
+	}, nil
+}
+
+// respondFilterError reports an invalid list filter, including the position
+// of the problem for ?filter= expressions
+func respondFilterError(c *gin.Context, err error) {
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "position": filterErr.Pos})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// GetEmployees retrieves all employees with optional department information,
+// optionally filtered by status, department and a ?filter= expression
 func GetEmployees(c *gin.Context) {
 	var employees []models.Employee
 
+	filters, err := employeeFilters(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
 	// Query with department preloading
-	result := database.DB.Preload("Department").Find(&employees)
+	result := database.DB.Preload("Department").Scopes(filters).Find(&employees)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
@@ -39,7 +117,7 @@ func GetEmployee(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": employee})
 }
 
-// CreateEmployee creates a new employee
+// CreateEmployee creates a new employee, and an invited user account when asked
 func CreateEmployee(c *gin.Context) {
 	var employee models.Employee
 	if err := c.ShouldBindJSON(&employee); err != nil {
@@ -47,16 +125,56 @@ func CreateEmployee(c *gin.Context) {
 		return
 	}
 
-	result := database.DB.Create(&employee)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// New hires start active unless explicitly created as inactive
+	if employee.Status == "" {
+		employee.Status = models.EmployeeStatusActive
+	}
+	if employee.Status != models.EmployeeStatusActive && employee.Status != models.EmployeeStatusInactive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New employees must be active or inactive"})
+		return
+	}
+
+	joined := employee.JoinDate
+	if joined.IsZero() {
+		joined = time.Now()
+	}
+
+	var inv *invitation
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := assignEmployeeCode(tx, codegen.DBCounter(tx), &employee); err != nil {
+			return err
+		}
+		if err := tx.Create(&employee).Error; err != nil {
+			return err
+		}
+		if err := recordEmployeeStatus(tx, employee.ID, "", employee.Status, nil, joined); err != nil {
+			return err
+		}
+		if !inviteRequested(c) {
+			return nil
+		}
+		var err error
+		inv, err = provisionAccount(tx, models.User{
+			Name:       employee.FirstName + " " + employee.LastName,
+			Email:      employee.Email,
+			Role:       models.UserRoleEmployee,
+			EmployeeID: &employee.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondCodeError(c, err)
 		return
 	}
 
 	// Reload with department information
 	database.DB.Preload("Department").First(&employee, employee.ID)
 
-	c.JSON(http.StatusCreated, gin.H{"data": employee})
+	response := gin.H{"data": employee}
+	if inv != nil {
+		response["invite"] = sendInvite(c.Request.Context(), inv)
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateEmployee updates an existing employee
@@ -69,6 +187,7 @@ func UpdateEmployee(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
 		return
 	}
+	previousStatus := employee.Status
 
 	// Bind updated data
 	if err := c.ShouldBindJSON(&employee); err != nil {
@@ -76,8 +195,20 @@ func UpdateEmployee(c *gin.Context) {
 		return
 	}
 
+	// Status only changes through the lifecycle endpoints so history is kept
+	if employee.Status != previousStatus {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status cannot be changed here; use the suspend, reinstate, terminate or rehire endpoints"})
+		return
+	}
+
+	// EmployeeID stays unique; clearing it draws a fresh generated code
+	if err := assignEmployeeCode(database.DB.WithContext(c.Request.Context()), codegen.DBCounter(database.DB.WithContext(c.Request.Context())), &employee); err != nil {
+		respondCodeError(c, err)
+		return
+	}
+
 	// Update employee
-	if err := database.DB.Save(&employee).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Save(&employee).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -99,7 +230,7 @@ func DeleteEmployee(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&employee).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&employee).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -107,20 +238,35 @@ func DeleteEmployee(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Employee deleted successfully"})
 }
 
-// GetEmployeesByDepartment retrieves employees by department ID
+// GetEmployeesByDepartment retrieves employees by department ID, optionally
+// including every department below it with ?include_descendants=true
 func GetEmployeesByDepartment(c *gin.Context) {
 	departmentID := c.Param("departmentId")
 	var employees []models.Employee
 
-	result := database.DB.Preload("Department").Where("department_id = ?", departmentID).Find(&employees)
+	query := database.DB.Preload("Department")
+	includeDescendants := c.Query("include_descendants") == "true"
+	if includeDescendants {
+		rootID, err := strconv.ParseUint(departmentID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
+			return
+		}
+		query = query.Where("department_id IN (?)", database.DB.Raw(subtreeQuery, []uint{uint(rootID)}))
+	} else {
+		query = query.Where("department_id = ?", departmentID)
+	}
+
+	result := query.Find(&employees)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  employees,
-		"count": len(employees),
+		"data":                employees,
+		"count":               len(employees),
+		"include_descendants": includeDescendants,
 	})
 }
 
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errStatusChanged means a concurrent transition changed the status after it
+// was checked
+var errStatusChanged = errors.New("status was changed by another request")
+
+// employeeTransition describes one lifecycle action and the states it may start from
+type employeeTransition struct {
+	From          []models.EmployeeStatus
+	To            models.EmployeeStatus
+	RequireReason bool
+}
+
+var employeeTransitions = map[string]employeeTransition{
+	"suspend": {
+		From: []models.EmployeeStatus{models.EmployeeStatusActive},
+		To:   models.EmployeeStatusSuspended,
+	},
+	"reinstate": {
+		From: []models.EmployeeStatus{models.EmployeeStatusSuspended, models.EmployeeStatusInactive},
+		To:   models.EmployeeStatusActive,
+	},
+	"terminate": {
+		From:          []models.EmployeeStatus{models.EmployeeStatusActive, models.EmployeeStatusInactive, models.EmployeeStatusSuspended},
+		To:            models.EmployeeStatusTerminated,
+		RequireReason: true,
+	},
+	"rehire": {
+		From: []models.EmployeeStatus{models.EmployeeStatusTerminated},
+		To:   models.EmployeeStatusActive,
+	},
+}
+
+func (t employeeTransition) allows(status models.EmployeeStatus) bool {
+	for _, s := range t.From {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type employeeTransitionInput struct {
+	Reason        *string    `json:"reason"`
+	EffectiveDate *time.Time `json:"effective_date"`
+}
+
+// SuspendEmployee moves an active employee to suspended
+func SuspendEmployee(c *gin.Context) {
+	transitionEmployee(c, "suspend")
+}
+
+// ReinstateEmployee returns a suspended or inactive employee to active
+func ReinstateEmployee(c *gin.Context) {
+	transitionEmployee(c, "reinstate")
+}
+
+// TerminateEmployee ends employment with a termination date and reason
+func TerminateEmployee(c *gin.Context) {
+	transitionEmployee(c, "terminate")
+}
+
+// RehireEmployee reactivates a terminated employee
+func RehireEmployee(c *gin.Context) {
+	transitionEmployee(c, "rehire")
+}
+
+func transitionEmployee(c *gin.Context, action string) {
+	transition := employeeTransitions[action]
+	id := c.Param("id")
+
+	var input employeeTransitionInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if transition.RequireReason && (input.Reason == nil || *input.Reason == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required to " + action + " an employee"})
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+	if !transition.allows(employee.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Cannot " + action + " an employee who is " + string(employee.Status),
+			"status": employee.Status,
+		})
+		return
+	}
+
+	effective := time.Now()
+	if input.EffectiveDate != nil {
+		effective = *input.EffectiveDate
+	}
+	effective = truncateToDate(effective)
+
+	updates := map[string]any{"status": transition.To}
+	switch transition.To {
+	case models.EmployeeStatusTerminated:
+		updates["termination_date"] = effective
+		updates["termination_reason"] = input.Reason
+	case models.EmployeeStatusActive:
+		if action == "rehire" {
+			updates["termination_date"] = nil
+			updates["termination_reason"] = nil
+			updates["join_date"] = effective
+		}
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Only one of two concurrent transitions may start from this status
+		result := tx.Model(&models.Employee{}).Where("id = ? AND status = ?", employee.ID, employee.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errStatusChanged
+		}
+		if err := recordEmployeeStatus(tx, employee.ID, employee.Status, transition.To, input.Reason, effective); err != nil {
+			return err
+		}
+		if transition.To == models.EmployeeStatusActive {
+			return nil
+		}
+		return releaseManagedDepartments(tx, employee.ID, effective, input.Reason)
+	})
+	if errors.Is(err, errStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Employee status was changed by another request"})
+		return
+	}
+	if err != nil {
+		respondManagerError(c, err)
+		return
+	}
+
+	database.DB.Preload("Department").First(&employee, employee.ID)
+
+	c.JSON(http.StatusOK, gin.H{"data": employee})
+}
+
+// releaseManagedDepartments clears the manager of every department run by an
+// employee who is no longer active, recording the change in the manager history
+func releaseManagedDepartments(tx *gorm.DB, employeeID uint, effective time.Time, reason *string) error {
+	var departments []models.Department
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("manager_id = ?", employeeID).
+		Order("id").
+		Find(&departments).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range departments {
+		if err := recordManagerChange(tx, departments[i].ID, nil, effective, reason); err != nil {
+			return err
+		}
+		if err := tx.Model(&departments[i]).Update("manager_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEmployeeStatus appends a status change to the employee's history
+func recordEmployeeStatus(tx *gorm.DB, employeeID uint, from, to models.EmployeeStatus, reason *string, effective time.Time) error {
+	return tx.Create(&models.EmployeeStatusHistory{
+		EmployeeID:    employeeID,
+		FromStatus:    from,
+		ToStatus:      to,
+		Reason:        reason,
+		EffectiveDate: truncateToDate(effective),
+	}).Error
+}
+
+// GetEmployeeStatusHistory lists the lifecycle transitions of an employee
+func GetEmployeeStatusHistory(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+	if err := database.DB.Unscoped().First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var history []models.EmployeeStatusHistory
+	err := database.DB.Where("employee_id = ?", employee.ID).Order("created_at DESC, id DESC").Find(&history).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   history,
+		"count":  len(history),
+		"status": employee.Status,
+	})
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errStudentNotActive  = errors.New("student is not active")
+	errSemesterEnded     = errors.New("semester has ended")
+	errAlreadyEnrolled   = errors.New("student is already enrolled or waitlisted for this course")
+	errEnrollmentNotLive = errors.New("enrollment is not enrolled or waitlisted")
+)
+
+// liveEnrollmentStatuses hold a seat or a waitlist place
+var liveEnrollmentStatuses = []models.EnrollmentStatus{models.EnrollmentStatusEnrolled, models.EnrollmentStatusWaitlisted}
+
+// seatEnrollmentStatuses count against a course's capacity
+var seatEnrollmentStatuses = []models.EnrollmentStatus{models.EnrollmentStatusEnrolled, models.EnrollmentStatusCompleted}
+
+type enrollInput struct {
+	StudentID  uint `json:"student_id" binding:"required"`
+	CourseID   uint `json:"course_id" binding:"required"`
+	SemesterID uint `json:"semester_id" binding:"required"`
+}
+
+// missingPrerequisites returns the prerequisites of the course the student has
+// not passed
+func missingPrerequisites(tx *gorm.DB, studentID, courseID uint) ([]models.Course, error) {
+	var missing []models.Course
+	err := tx.Joins("JOIN course_prerequisites cp ON cp.prerequisite_id = courses.id").
+		Where("cp.course_id = ?", courseID).
+		Where("NOT EXISTS (?)", tx.Model(&models.Enrollment{}).
+			Select("1").
+			Joins("JOIN grades ON grades.enrollment_id = enrollments.id AND grades.passed").
+			Where("enrollments.course_id = courses.id AND enrollments.student_id = ? AND enrollments.status = ?",
+				studentID, models.EnrollmentStatusCompleted)).
+		Order("courses.code").
+		Find(&missing).Error
+	return missing, err
+}
+
+// lockCourse serialises enrollment changes for a course so capacity checks
+// and waitlist promotion cannot race
+func lockCourse(tx *gorm.DB, courseID uint) (models.Course, error) {
+	var course models.Course
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&course, courseID).Error
+	return course, err
+}
+
+// enrolledCount counts the seats taken in a course for a semester. A student
+// graded before the semester ends keeps their seat.
+func enrolledCount(tx *gorm.DB, courseID, semesterID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Enrollment{}).
+		Where("course_id = ? AND semester_id = ? AND status IN ?", courseID, semesterID, seatEnrollmentStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// fillFromWaitlist promotes waitlisted active students, first come first
+// served, until the course is full for the semester
+func fillFromWaitlist(tx *gorm.DB, courseID, semesterID uint) error {
+	course, err := lockCourse(tx, courseID)
+	if err != nil {
+		return err
+	}
+	enrolled, err := enrolledCount(tx, courseID, semesterID)
+	if err != nil {
+		return err
+	}
+	free := course.Capacity - int(enrolled)
+	if free <= 0 {
+		return nil
+	}
+
+	var waiting []models.Enrollment
+	err = tx.Joins("JOIN students ON students.id = enrollments.student_id AND students.status = ?", models.StudentStatusActive).
+		Where("enrollments.course_id = ? AND enrollments.semester_id = ? AND enrollments.status = ?",
+			courseID, semesterID, models.EnrollmentStatusWaitlisted).
+		Order("enrollments.waitlisted_at, enrollments.id").
+		Limit(free).
+		Find(&waiting).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range waiting {
+		waiting[i].Status = models.EnrollmentStatusEnrolled
+		waiting[i].EnrolledAt = &now
+		if err := tx.Save(&waiting[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setWaitlistPositions fills in WaitlistPosition for waitlisted enrollments
+func setWaitlistPositions(enrollments []models.Enrollment) error {
+	for i := range enrollments {
+		e := &enrollments[i]
+		if e.Status != models.EnrollmentStatusWaitlisted || e.WaitlistedAt == nil {
+			continue
+		}
+		var ahead int64
+		err := database.DB.Model(&models.Enrollment{}).
+			Where("course_id = ? AND semester_id = ? AND status = ?", e.CourseID, e.SemesterID, models.EnrollmentStatusWaitlisted).
+			Where("(waitlisted_at, id) < (?, ?)", *e.WaitlistedAt, e.ID).
+			Count(&ahead).Error
+		if err != nil {
+			return err
+		}
+		position := int(ahead) + 1
+		e.WaitlistPosition = &position
+	}
+	return nil
+}
+
+// EnrollStudent enrolls a student in a course for a semester once the
+// prerequisites are met, or waitlists them when the course is full
+func EnrollStudent(c *gin.Context) {
+	var input enrollInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var student models.Student
+	if err := database.DB.First(&student, input.StudentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+	var course models.Course
+	if err := database.DB.First(&course, input.CourseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+	var semester models.Semester
+	if err := database.DB.First(&semester, input.SemesterID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Semester not found"})
+		return
+	}
+
+	if student.Status != models.StudentStatusActive {
+		respondEnrollmentError(c, errStudentNotActive)
+		return
+	}
+	if truncateToDate(time.Now()).After(semester.EndDate) {
+		respondEnrollmentError(c, errSemesterEnded)
+		return
+	}
+
+	missing, err := missingPrerequisites(database.DB, student.ID, course.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":                 "Prerequisites not met",
+			"missing_prerequisites": missing,
+		})
+		return
+	}
+
+	enrollment := models.Enrollment{
+		StudentID:  student.ID,
+		CourseID:   course.ID,
+		SemesterID: semester.ID,
+	}
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockCourse(tx, course.ID)
+		if err != nil {
+			return err
+		}
+
+		var live int64
+		err = tx.Model(&models.Enrollment{}).
+			Where("student_id = ? AND course_id = ? AND semester_id = ? AND status IN ?",
+				student.ID, course.ID, semester.ID, liveEnrollmentStatuses).
+			Count(&live).Error
+		if err != nil {
+			return err
+		}
+		if live > 0 {
+			return errAlreadyEnrolled
+		}
+
+		enrolled, err := enrolledCount(tx, course.ID, semester.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if int(enrolled) < locked.Capacity {
+			enrollment.Status = models.EnrollmentStatusEnrolled
+			enrollment.EnrolledAt = &now
+		} else {
+			enrollment.Status = models.EnrollmentStatusWaitlisted
+			enrollment.WaitlistedAt = &now
+		}
+		return tx.Create(&enrollment).Error
+	})
+	if err != nil {
+		respondEnrollmentError(c, err)
+		return
+	}
+
+	enrollments := []models.Enrollment{enrollment}
+	if err := setWaitlistPositions(enrollments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": enrollments[0]})
+}
+
+// DropEnrollment drops an enrollment or waitlist place. A freed seat goes to
+// the next waitlisted student.
+func DropEnrollment(c *gin.Context) {
+	id := c.Param("id")
+	var enrollment models.Enrollment
+
+	if err := database.DB.First(&enrollment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCourse(tx, enrollment.CourseID); err != nil {
+			return err
+		}
+		var err error
+		if enrollment, err = lockEnrollment(tx, enrollment.ID); err != nil {
+			return err
+		}
+		if enrollment.Status != models.EnrollmentStatusEnrolled && enrollment.Status != models.EnrollmentStatusWaitlisted {
+			return errEnrollmentNotLive
+		}
+
+		freesSeat := enrollment.Status == models.EnrollmentStatusEnrolled
+		now := time.Now()
+		enrollment.Status = models.EnrollmentStatusDropped
+		enrollment.DroppedAt = &now
+		if err := tx.Save(&enrollment).Error; err != nil {
+			return err
+		}
+		if freesSeat {
+			return fillFromWaitlist(tx, enrollment.CourseID, enrollment.SemesterID)
+		}
+		return nil
+	})
+	if err != nil {
+		respondEnrollmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+// GetEnrollment retrieves a single enrollment with its student, course and semester
+func GetEnrollment(c *gin.Context) {
+	id := c.Param("id")
+	var enrollment models.Enrollment
+
+	err := database.DB.Preload("Student").Preload("Course").Preload("Semester").Preload("Grade").First(&enrollment, id).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
+		return
+	}
+
+	enrollments := []models.Enrollment{enrollment}
+	if err := setWaitlistPositions(enrollments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollments[0]})
+}
+
+// GetStudentEnrollments lists a student's enrollments, optionally filtered by
+// ?semester_id= and ?status=
+func GetStudentEnrollments(c *gin.Context) {
+	id := c.Param("id")
+	var student models.Student
+
+	if err := database.DB.First(&student, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	query := database.DB.Preload("Course").Preload("Semester").Preload("Grade").
+		Joins("JOIN semesters ON semesters.id = enrollments.semester_id").
+		Where("enrollments.student_id = ?", student.ID).
+		Order("semesters.start_date DESC, enrollments.id")
+	listEnrollments(c, query)
+}
+
+// enrollmentStatusOrder sorts students holding a seat before the waitlist,
+// with dropped enrollments last
+const enrollmentStatusOrder = `CASE enrollments.status
+	WHEN 'enrolled' THEN 0 WHEN 'completed' THEN 1 WHEN 'waitlisted' THEN 2 ELSE 3 END`
+
+// GetCourseEnrollments lists a course's enrollments, enrolled students first
+// and then the waitlist in order, optionally filtered by ?semester_id= and ?status=
+func GetCourseEnrollments(c *gin.Context) {
+	id := c.Param("id")
+	var course models.Course
+
+	if err := database.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	query := database.DB.Preload("Student").Preload("Semester").Preload("Grade").
+		Where("enrollments.course_id = ?", course.ID).
+		Order("enrollments.semester_id DESC").
+		Order(enrollmentStatusOrder).
+		Order("enrollments.enrolled_at, enrollments.waitlisted_at, enrollments.id")
+	listEnrollments(c, query)
+}
+
+func listEnrollments(c *gin.Context, query *gorm.DB) {
+	if semesterID := c.Query("semester_id"); semesterID != "" {
+		query = query.Where("enrollments.semester_id = ?", semesterID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("enrollments.status = ?", status)
+	}
+
+	var enrollments []models.Enrollment
+	if err := query.Find(&enrollments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := setWaitlistPositions(enrollments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  enrollments,
+		"count": len(enrollments),
+	})
+}
+
+// respondEnrollmentError maps enrollment errors to HTTP responses
+func respondEnrollmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errAlreadyEnrolled), errors.Is(err, errEnrollmentNotLive),
+		errors.Is(err, errStudentNotActive), errors.Is(err, errSemesterEnded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
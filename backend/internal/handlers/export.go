@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/export"
+	"project-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportColumn is one selectable column of an export and how to read it from a row
+type exportColumn[T any] struct {
+	Name  string
+	Value func(row *T) any
+}
+
+var studentExportColumns = []exportColumn[models.Student]{
+	{"id", func(s *models.Student) any { return s.ID }},
+	{"student_code", func(s *models.Student) any { return s.StudentCode }},
+	{"first_name", func(s *models.Student) any { return s.FirstName }},
+	{"last_name", func(s *models.Student) any { return s.LastName }},
+	{"email", func(s *models.Student) any { return s.Email }},
+	{"phone", func(s *models.Student) any { return s.Phone }},
+	{"date_of_birth", func(s *models.Student) any { return formatDate(s.DateOfBirth) }},
+	{"address", func(s *models.Student) any { return s.Address }},
+	{"major", func(s *models.Student) any { return s.Major }},
+	{"year", func(s *models.Student) any { return s.Year }},
+	{"gpa", func(s *models.Student) any { return s.GPA }},
+	{"status", func(s *models.Student) any { return s.Status }},
+	{"created_at", func(s *models.Student) any { return s.CreatedAt }},
+}
+
+// employeeExportRow is an employee joined with its department name
+type employeeExportRow struct {
+	ID             uint
+	EmployeeID     *string
+	FirstName      string
+	LastName       string
+	Email          string
+	Phone          *string
+	DepartmentID   *uint
+	DepartmentName *string
+	Position       *string
+	Status         models.EmployeeStatus
+	JoinDate       time.Time
+	CreatedAt      time.Time
+}
+
+var employeeExportColumns = []exportColumn[employeeExportRow]{
+	{"id", func(e *employeeExportRow) any { return e.ID }},
+	{"employee_id", func(e *employeeExportRow) any { return e.EmployeeID }},
+	{"first_name", func(e *employeeExportRow) any { return e.FirstName }},
+	{"last_name", func(e *employeeExportRow) any { return e.LastName }},
+	{"email", func(e *employeeExportRow) any { return e.Email }},
+	{"phone", func(e *employeeExportRow) any { return e.Phone }},
+	{"department_id", func(e *employeeExportRow) any { return e.DepartmentID }},
+	{"department_name", func(e *employeeExportRow) any { return e.DepartmentName }},
+	{"position", func(e *employeeExportRow) any { return e.Position }},
+	{"status", func(e *employeeExportRow) any { return e.Status }},
+	{"join_date", func(e *employeeExportRow) any { return formatDate(&e.JoinDate) }},
+	{"created_at", func(e *employeeExportRow) any { return e.CreatedAt }},
+}
+
+// ExportStudents streams students as CSV, XLSX, JSON or JSON Lines, honouring
+// the same filters as GetStudents
+func ExportStudents(c *gin.Context) {
+	filters, err := studentFilters(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	query := database.DB.Model(&models.Student{}).Scopes(filters).Order("id")
+	streamExport(c, "students", query, studentExportColumns)
+}
+
+// ExportEmployees streams employees with their department name, honouring the
+// same filters as GetEmployees
+func ExportEmployees(c *gin.Context) {
+	filters, err := employeeFilters(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	query := database.DB.Model(&models.Employee{}).
+		Select(`employees.id, employees.employee_id, employees.first_name, employees.last_name,
+			employees.email, employees.phone, employees.department_id, departments.name AS department_name,
+			employees.position, employees.status, employees.join_date, employees.created_at`).
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id").
+		Scopes(filters).
+		Order("employees.id")
+	streamExport(c, "employees", query, employeeExportColumns)
+}
+
+// streamExport writes the rows of query one at a time in the requested format.
+// ?columns= selects and orders columns, ?bom=true prefixes CSV with a UTF-8 BOM.
+func streamExport[T any](c *gin.Context, name string, query *gorm.DB, available []exportColumn[T]) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if !export.IsSupported(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, xlsx, json, jsonl"})
+		return
+	}
+
+	columns, err := selectExportColumns(c.Query("columns"), available)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102"), format))
+
+	writer, err := export.NewWriter(format, c.Writer, export.Options{BOM: c.Query("bom") == "true"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	if err := writer.WriteHeader(names); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := writeExportRows(rows, writer, columns); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+func writeExportRows[T any](rows *sql.Rows, writer export.Writer, columns []exportColumn[T]) error {
+	values := make([]any, len(columns))
+	for rows.Next() {
+		var row T
+		if err := database.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		for i, col := range columns {
+			values[i] = col.Value(&row)
+		}
+		if err := writer.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// selectExportColumns resolves a comma separated ?columns= list; empty means all
+func selectExportColumns[T any](requested string, available []exportColumn[T]) ([]exportColumn[T], error) {
+	if requested == "" {
+		return available, nil
+	}
+
+	var selected []exportColumn[T]
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, col := range available {
+			if col.Name == name {
+				selected = append(selected, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return selected, nil
+}
+
+func formatDate(t *time.Time) any {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
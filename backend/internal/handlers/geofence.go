@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/geo"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	geofenceModeReject = "reject"
+	geofenceModeFlag   = "flag"
+)
+
+var (
+	errNoGeofence      = errors.New("mobile check-in is not enabled for the department")
+	errOutsideGeofence = errors.New("location is outside the allowed geofence")
+	errLowAccuracy     = errors.New("location accuracy is too low")
+)
+
+// geoFix is a GPS position reported by a phone together with the geofence verdict
+type geoFix struct {
+	Point      geo.Point
+	Accuracy   float64
+	GeofenceID *uint
+	FlagReason string
+}
+
+// flag marks the record for review when the fix did not pass the geofence
+func (f *geoFix) flag(record *models.AttendanceRecord) {
+	if f.FlagReason == "" {
+		return
+	}
+	reason := f.FlagReason
+	record.Flagged = true
+	record.FlagReason = &reason
+}
+
+type geofenceInput struct {
+	Name         string              `json:"name" binding:"required"`
+	Type         models.GeofenceType `json:"type" binding:"required,oneof=circle polygon"`
+	CenterLat    *float64            `json:"center_lat"`
+	CenterLng    *float64            `json:"center_lng"`
+	RadiusMeters *float64            `json:"radius_meters" binding:"omitempty,gt=0"`
+	Polygon      models.GeoPolygon   `json:"polygon"`
+}
+
+func (in geofenceInput) validate() error {
+	switch in.Type {
+	case models.GeofenceTypeCircle:
+		if in.CenterLat == nil || in.CenterLng == nil || in.RadiusMeters == nil {
+			return errors.New("center_lat, center_lng and radius_meters are required for circle geofences")
+		}
+		if !geo.Valid(geo.Point{Lat: *in.CenterLat, Lng: *in.CenterLng}) {
+			return errors.New("center is not a valid coordinate")
+		}
+	case models.GeofenceTypePolygon:
+		if len(in.Polygon) < 3 {
+			return errors.New("polygon geofences need at least 3 points")
+		}
+		for _, p := range in.Polygon {
+			if !geo.Valid(p) {
+				return fmt.Errorf("polygon point %v is not a valid coordinate", p)
+			}
+		}
+	}
+	return nil
+}
+
+func (in geofenceInput) apply(g *models.Geofence) {
+	g.Name = in.Name
+	g.Type = in.Type
+	g.CenterLat, g.CenterLng, g.RadiusMeters, g.Polygon = nil, nil, nil, nil
+	if in.Type == models.GeofenceTypeCircle {
+		g.CenterLat, g.CenterLng, g.RadiusMeters = in.CenterLat, in.CenterLng, in.RadiusMeters
+	} else {
+		g.Polygon = in.Polygon
+	}
+}
+
+// GetDepartmentGeofences retrieves the geofences attached to a department
+func GetDepartmentGeofences(c *gin.Context) {
+	id := c.Param("id")
+	var geofences []models.Geofence
+
+	if err := database.DB.Where("department_id = ?", id).Find(&geofences).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  geofences,
+		"count": len(geofences),
+	})
+}
+
+// CreateDepartmentGeofence attaches a circle or polygon geofence to a department
+func CreateDepartmentGeofence(c *gin.Context) {
+	id := c.Param("id")
+	var department models.Department
+	if err := database.DB.First(&department, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	var input geofenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	geofence := models.Geofence{DepartmentID: department.ID}
+	input.apply(&geofence)
+	if err := database.DB.WithContext(c.Request.Context()).Create(&geofence).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": geofence})
+}
+
+// UpdateGeofence replaces the shape of an existing geofence
+func UpdateGeofence(c *gin.Context) {
+	id := c.Param("id")
+	var geofence models.Geofence
+	if err := database.DB.First(&geofence, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Geofence not found"})
+		return
+	}
+
+	var input geofenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.apply(&geofence)
+	if err := database.DB.WithContext(c.Request.Context()).Save(&geofence).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": geofence})
+}
+
+// DeleteGeofence soft deletes a geofence
+func DeleteGeofence(c *gin.Context) {
+	id := c.Param("id")
+	var geofence models.Geofence
+
+	if err := database.DB.First(&geofence, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Geofence not found"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&geofence).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Geofence deleted successfully"})
+}
+
+type mobileAttendanceRequest struct {
+	Latitude  *float64 `json:"latitude" binding:"required"`
+	Longitude *float64 `json:"longitude" binding:"required"`
+	Accuracy  *float64 `json:"accuracy" binding:"required,gte=0"`
+}
+
+// MobileCheckIn checks the signed-in employee in from a phone after checking
+// the GPS fix against the geofences of their department
+func MobileCheckIn(c *gin.Context) {
+	employee, fix, ok := bindMobileAttendance(c)
+	if !ok {
+		return
+	}
+
+	record, err := checkInEmployee(c.Request.Context(), employee, attendanceSource{Location: fix}, time.Now())
+	if err != nil {
+		respondAttendanceError(c, err, record)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+// MobileCheckOut checks the signed-in employee out from a phone
+func MobileCheckOut(c *gin.Context) {
+	employee, fix, ok := bindMobileAttendance(c)
+	if !ok {
+		return
+	}
+
+	record, err := checkOutEmployee(c.Request.Context(), employee, attendanceSource{Location: fix}, time.Now())
+	if err != nil {
+		respondAttendanceError(c, err, record)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+func bindMobileAttendance(c *gin.Context) (models.Employee, *geoFix, bool) {
+	var employee models.Employee
+	var req mobileAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return employee, nil, false
+	}
+
+	point := geo.Point{Lat: *req.Latitude, Lng: *req.Longitude}
+	if !geo.Valid(point) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude/longitude out of range"})
+		return employee, nil, false
+	}
+
+	// Employees can only clock themselves in
+	user := middleware.CurrentUser(c)
+	if user.EmployeeID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account is not linked to an employee"})
+		return employee, nil, false
+	}
+	if err := database.DB.Preload("Shift").First(&employee, *user.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return employee, nil, false
+	}
+
+	fix, err := evaluateGeofence(employee, point, *req.Accuracy)
+	switch {
+	case errors.Is(err, errNoGeofence):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Mobile check-in is not enabled for this department"})
+		return employee, nil, false
+	case errors.Is(err, errOutsideGeofence):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Location is outside the allowed geofence", "latitude": point.Lat, "longitude": point.Lng})
+		return employee, nil, false
+	case errors.Is(err, errLowAccuracy):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Location accuracy is too low", "accuracy": *req.Accuracy})
+		return employee, nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return employee, nil, false
+	}
+
+	return employee, fix, true
+}
+
+// evaluateGeofence matches a GPS fix against the employee's department
+// geofences. Depending on GEOFENCE_MODE a miss is rejected or only flagged.
+func evaluateGeofence(employee models.Employee, point geo.Point, accuracy float64) (*geoFix, error) {
+	if employee.DepartmentID == nil {
+		return nil, errNoGeofence
+	}
+
+	var geofences []models.Geofence
+	if err := database.DB.Where("department_id = ?", *employee.DepartmentID).Find(&geofences).Error; err != nil {
+		return nil, err
+	}
+	if len(geofences) == 0 {
+		return nil, errNoGeofence
+	}
+
+	fix := &geoFix{Point: point, Accuracy: accuracy}
+	for _, g := range geofences {
+		if g.Contains(point) {
+			id := g.ID
+			fix.GeofenceID = &id
+			break
+		}
+	}
+
+	var violation error
+	if fix.GeofenceID == nil {
+		violation = errOutsideGeofence
+	} else if accuracy > config.Float("GEOFENCE_MAX_ACCURACY_METERS", 100) {
+		violation = errLowAccuracy
+	}
+	if violation == nil {
+		return fix, nil
+	}
+
+	if config.String("GEOFENCE_MODE", geofenceModeReject) == geofenceModeFlag {
+		fix.FlagReason = violation.Error()
+		return fix, nil
+	}
+	return nil, violation
+}
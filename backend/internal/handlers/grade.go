@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/grading"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errGPAReadOnly = errors.New("gpa is computed from grades and cannot be set")
+	errNotGradable = errors.New("only enrolled students can be graded")
+)
+
+// cumulativeGPAQuery averages grade points weighted by credits. A retaken
+// course counts once, with its best grade.
+const cumulativeGPAQuery = `
+SELECT ROUND(SUM(best.points * best.credits) / NULLIF(SUM(best.credits), 0), 2) AS gpa,
+	COALESCE(SUM(best.credits), 0) AS credits,
+	COALESCE(SUM(best.credits) FILTER (WHERE best.passed), 0) AS earned_credits
+FROM (
+	SELECT DISTINCT ON (e.course_id) g.points, g.passed, c.credits
+	FROM enrollments e
+	JOIN grades g ON g.enrollment_id = e.id
+	JOIN courses c ON c.id = e.course_id
+	WHERE e.student_id = ? AND e.status = 'completed'
+	ORDER BY e.course_id, g.points DESC
+) best`
+
+// semesterGPAQuery averages the grades of each semester on their own
+const semesterGPAQuery = `
+SELECT s.id AS semester_id, s.code, s.name,
+	ROUND(SUM(g.points * c.credits) / NULLIF(SUM(c.credits), 0), 2) AS gpa,
+	SUM(c.credits) AS credits
+FROM enrollments e
+JOIN grades g ON g.enrollment_id = e.id
+JOIN courses c ON c.id = e.course_id
+JOIN semesters s ON s.id = e.semester_id
+WHERE e.student_id = ? AND e.status = 'completed'
+GROUP BY s.id, s.code, s.name, s.start_date
+ORDER BY s.start_date`
+
+type gpaSummary struct {
+	GPA           *float64 `json:"gpa"`
+	Credits       int      `json:"credits"`
+	EarnedCredits int      `json:"earned_credits"`
+}
+
+type semesterGPA struct {
+	SemesterID uint     `json:"semester_id"`
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	GPA        *float64 `json:"gpa"`
+	Credits    int      `json:"credits"`
+}
+
+type gradeInput struct {
+	Score  *float64 `json:"score"`
+	Letter *string  `json:"letter"`
+}
+
+// gradingScale returns the scale configured in GRADING_SCALE
+func gradingScale() (grading.Scale, error) {
+	return grading.Parse(config.String("GRADING_SCALE", grading.DefaultSpec))
+}
+
+// ValidateGradingScale checks the configured GRADING_SCALE
+func ValidateGradingScale() error {
+	_, err := gradingScale()
+	return err
+}
+
+// recomputeStudentGPA stores the student's cumulative GPA. It runs in the
+// transaction that changed a grade so GPA never disagrees with the grades.
+func recomputeStudentGPA(tx *gorm.DB, studentID uint) error {
+	var summary gpaSummary
+	if err := tx.Raw(cumulativeGPAQuery, studentID).Scan(&summary).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Student{}).Where("id = ?", studentID).Update("gpa", summary.GPA).Error
+}
+
+// lockEnrollment re-reads an enrollment inside tx and locks it, so a
+// concurrent drop or regrade cannot be overwritten with stale values
+func lockEnrollment(tx *gorm.DB, id uint) (models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&enrollment, id).Error
+	return enrollment, err
+}
+
+// SetEnrollmentGrade records the grade of an enrollment from either a
+// 10-point score or a letter grade, completes the enrollment and recomputes
+// the student's GPA
+func SetEnrollmentGrade(c *gin.Context) {
+	id := c.Param("id")
+	var enrollment models.Enrollment
+
+	if err := database.DB.First(&enrollment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
+		return
+	}
+
+	var input gradeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (input.Score == nil) == (input.Letter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either score or letter"})
+		return
+	}
+
+	scale, err := gradingScale()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var band grading.Band
+	if input.Score != nil {
+		band, err = scale.FromScore(*input.Score)
+	} else {
+		band, err = scale.FromLetter(*input.Letter)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var grade models.Grade
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		enrollment, err = lockEnrollment(tx, enrollment.ID)
+		if err != nil {
+			return err
+		}
+		if enrollment.Status != models.EnrollmentStatusEnrolled && enrollment.Status != models.EnrollmentStatusCompleted {
+			return errNotGradable
+		}
+
+		if err := tx.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		grade.EnrollmentID = enrollment.ID
+		grade.Score = input.Score
+		grade.Letter = band.Letter
+		grade.Points = band.Points
+		grade.Passed = band.Passing()
+		grade.GradedAt = time.Now()
+		if err := tx.Save(&grade).Error; err != nil {
+			return err
+		}
+
+		if enrollment.Status != models.EnrollmentStatusCompleted {
+			enrollment.Status = models.EnrollmentStatusCompleted
+			if err := tx.Model(&enrollment).Update("status", enrollment.Status).Error; err != nil {
+				return err
+			}
+		}
+		return recomputeStudentGPA(tx, enrollment.StudentID)
+	})
+	if errors.Is(err, errNotGradable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only enrolled students can be graded", "status": enrollment.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": grade})
+}
+
+// DeleteEnrollmentGrade removes a grade recorded in error, returning the
+// enrollment to enrolled and recomputing the student's GPA
+func DeleteEnrollmentGrade(c *gin.Context) {
+	id := c.Param("id")
+	var enrollment models.Enrollment
+
+	if err := database.DB.First(&enrollment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		enrollment, err = lockEnrollment(tx, enrollment.ID)
+		if err != nil {
+			return err
+		}
+		var grade models.Grade
+		if err := tx.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&grade).Error; err != nil {
+			return err
+		}
+		// A dropped enrollment stays dropped
+		if enrollment.Status == models.EnrollmentStatusCompleted {
+			enrollment.Status = models.EnrollmentStatusEnrolled
+			if err := tx.Model(&enrollment).Update("status", enrollment.Status).Error; err != nil {
+				return err
+			}
+		}
+		return recomputeStudentGPA(tx, enrollment.StudentID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Grade not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grade deleted successfully"})
+}
+
+// GetStudentGPA returns a student's cumulative GPA and the GPA of each semester
+func GetStudentGPA(c *gin.Context) {
+	id := c.Param("id")
+	var student models.Student
+
+	if err := database.DB.First(&student, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	var summary gpaSummary
+	if err := database.DB.Raw(cumulativeGPAQuery, student.ID).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	semesters := []semesterGPA{}
+	if err := database.DB.Raw(semesterGPAQuery, student.ID).Scan(&semesters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"student_id":     student.ID,
+		"gpa":            summary.GPA,
+		"credits":        summary.Credits,
+		"earned_credits": summary.EarnedCredits,
+		"semesters":      semesters,
+	}})
+}
+
+// GetGradingScale returns the grading scale in force
+func GetGradingScale(c *gin.Context) {
+	scale, err := gradingScale()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": scale})
+}
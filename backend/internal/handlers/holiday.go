@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errHolidayExists = errors.New("a holiday already exists on this date")
+
+type holidayInput struct {
+	Date time.Time `json:"date" binding:"required"`
+	Name string    `json:"name" binding:"required"`
+}
+
+// saveHoliday stores holiday after checking its date is free, then reprices
+// pending overtime on the dates that changed
+func saveHoliday(tx *gorm.DB, holiday *models.Holiday, previous *time.Time) error {
+	var taken int64
+	if err := tx.Model(&models.Holiday{}).Where("date = ? AND id <> ?", holiday.Date.Format("2006-01-02"), holiday.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errHolidayExists
+	}
+	if err := tx.Save(holiday).Error; err != nil {
+		return err
+	}
+	if previous != nil && !previous.Equal(holiday.Date) {
+		if err := repriceOvertime(tx, *previous); err != nil {
+			return err
+		}
+	}
+	return repriceOvertime(tx, holiday.Date)
+}
+
+// repriceOvertime updates the day type and multiplier of pending overtime
+// requests on date; reviewed requests keep what was approved
+func repriceOvertime(tx *gorm.DB, date time.Time) error {
+	dayType, err := dayTypeFor(tx, date)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.OvertimeRequest{}).
+		Where("date = ? AND status = ?", date.Format("2006-01-02"), models.OvertimeStatusPending).
+		Updates(map[string]any{
+			"day_type":   dayType,
+			"multiplier": loadOvertimePolicy().Multipliers[dayType],
+		}).Error
+}
+
+// holidayDates returns the dates of the holidays between from and to, keyed
+// as YYYY-MM-DD
+func holidayDates(tx *gorm.DB, from, to time.Time) (map[string]bool, error) {
+	var dates []time.Time
+	err := tx.Model(&models.Holiday{}).
+		Where("date >= ? AND date <= ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Pluck("date", &dates).Error
+	if err != nil {
+		return nil, err
+	}
+	holidays := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		holidays[date.Format("2006-01-02")] = true
+	}
+	return holidays, nil
+}
+
+// isWorkingDay reports whether day is a weekday that is not a holiday, the
+// days leave is counted in
+func isWorkingDay(day time.Time, holidays map[string]bool) bool {
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !holidays[day.Format("2006-01-02")]
+}
+
+func respondHolidayError(c *gin.Context, err error) {
+	if errors.Is(err, errHolidayExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GetHolidays lists public holidays in date order, optionally for one year
+func GetHolidays(c *gin.Context) {
+	query := database.DB.Order("date")
+	if year := c.Query("year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a number"})
+			return
+		}
+		query = query.Where("date >= ? AND date < ?", time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(y+1, 1, 1, 0, 0, 0, 0, time.UTC))
+	}
+
+	var holidays []models.Holiday
+	if err := query.Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  holidays,
+		"count": len(holidays),
+	})
+}
+
+// CreateHoliday adds a public holiday, which overtime on that day is paid at
+// the holiday multiplier for
+func CreateHoliday(c *gin.Context) {
+	var input holidayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	holiday := models.Holiday{Date: truncateToDate(input.Date), Name: input.Name}
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return saveHoliday(tx, &holiday, nil)
+	})
+	if err != nil {
+		respondHolidayError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": holiday})
+}
+
+// UpdateHoliday renames or moves a public holiday
+func UpdateHoliday(c *gin.Context) {
+	id := c.Param("id")
+	var holiday models.Holiday
+	if err := database.DB.First(&holiday, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	var input holidayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := holiday.Date
+	holiday.Date = truncateToDate(input.Date)
+	holiday.Name = input.Name
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return saveHoliday(tx, &holiday, &previous)
+	})
+	if err != nil {
+		respondHolidayError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": holiday})
+}
+
+// DeleteHoliday removes a public holiday
+func DeleteHoliday(c *gin.Context) {
+	id := c.Param("id")
+	var holiday models.Holiday
+	if err := database.DB.First(&holiday, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&holiday).Error; err != nil {
+			return err
+		}
+		return repriceOvertime(tx, holiday.Date)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted successfully"})
+}
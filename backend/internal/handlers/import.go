@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"project-backend/internal/codegen"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/importer"
+	"project-backend/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	importEntityStudents  = "students"
+	importEntityEmployees = "employees"
+
+	// maxStoredImportErrors caps the errors kept on a job; failed_count still counts all
+	maxStoredImportErrors = 1000
+	importProgressEvery   = 100
+)
+
+var errImportRolledBack = errors.New("import rolled back")
+
+// importKeys lists the columns each entity can be upserted by; the first is the default
+var importKeys = map[string][]string{
+	importEntityStudents:  {"student_code", "email"},
+	importEntityEmployees: {"employee_id", "email"},
+}
+
+// rowError is a validation failure on a single import row
+type rowError struct {
+	Field   string
+	Message string
+}
+
+func (e *rowError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// rowApplier validates one row and creates or updates the matching record,
+// drawing generated codes from codes, and reports whether a new record was
+// created
+type rowApplier func(tx *gorm.DB, codes codegen.Counter, row importer.Row, key string) (bool, error)
+
+// ImportStudents imports students from an uploaded CSV or XLSX file
+func ImportStudents(c *gin.Context) {
+	startImport(c, importEntityStudents, applyStudentRow)
+}
+
+// ImportEmployees imports employees from an uploaded CSV or XLSX file
+func ImportEmployees(c *gin.Context) {
+	startImport(c, importEntityEmployees, applyEmployeeRow)
+}
+
+// startImport stores the upload, creates the import job and runs it, in the
+// background when the file is large or ?async=true is given
+func startImport(c *gin.Context, entity string, apply rowApplier) {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	format, err := importer.DetectFormat(upload.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := models.ImportMode(c.DefaultPostForm("mode", c.DefaultQuery("mode", string(models.ImportModeAllOrNothing))))
+	if mode != models.ImportModeAllOrNothing && mode != models.ImportModePartial {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be all_or_nothing or partial"})
+		return
+	}
+
+	key := c.DefaultPostForm("key", c.DefaultQuery("key", importKeys[entity][0]))
+	if !containsString(importKeys[entity], key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key must be one of " + strings.Join(importKeys[entity], ", ")})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of file header to field name"})
+			return
+		}
+	}
+
+	dryRun := formBool(c, "dry_run")
+	async := formBool(c, "async") || upload.Size > int64(config.Int("IMPORT_ASYNC_THRESHOLD_BYTES", 1<<20))
+
+	tmp, err := os.CreateTemp("", "import-*"+filepath.Ext(upload.Filename))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tmp.Close()
+	if err := c.SaveUploadedFile(upload, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	job := models.ImportJob{
+		EntityType: entity,
+		FileName:   upload.Filename,
+		Status:     models.ImportStatusPending,
+		Mode:       mode,
+		DryRun:     dryRun,
+		UpsertKey:  key,
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The import outlives the request when async; keep its audit info only
+	ctx := context.WithoutCancel(c.Request.Context())
+	if async {
+		go runImportJob(ctx, job, tmp.Name(), format, mapping, apply)
+		c.JSON(http.StatusAccepted, gin.H{"data": job})
+		return
+	}
+
+	runImportJob(ctx, job, tmp.Name(), format, mapping, apply)
+	database.DB.First(&job, job.ID)
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// runImportJob processes every row inside one transaction. Each row runs in a
+// savepoint so a bad row never poisons the others; at the end the transaction
+// is committed, or rolled back for dry runs and failed all-or-nothing imports.
+// Generated codes are drawn outside the transaction so concurrent creates are
+// not blocked on the sequence for the whole import; numbers drawn by a rolled
+// back import are skipped, and dry runs only preview the codes.
+func runImportJob(ctx context.Context, job models.ImportJob, path, format string, mapping map[string]string, apply rowApplier) {
+	defer os.Remove(path)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("import job %d panicked: %v", job.ID, r)
+			finishImportJob(&job, models.ImportStatusFailed, fmt.Sprint(r))
+		}
+	}()
+
+	now := time.Now()
+	job.Status = models.ImportStatusRunning
+	job.StartedAt = &now
+	total, err := importer.CountRows(path, format)
+	if err != nil {
+		finishImportJob(&job, models.ImportStatusFailed, err.Error())
+		return
+	}
+	job.TotalRows = total
+	database.DB.Model(&job).Updates(map[string]any{"status": job.Status, "started_at": now, "total_rows": total})
+
+	reader, err := importer.Open(path, format, mapping)
+	if err != nil {
+		finishImportJob(&job, models.ImportStatusFailed, err.Error())
+		return
+	}
+	defer reader.Close()
+
+	codes := codegen.DBCounter(database.DB.WithContext(ctx))
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if job.DryRun {
+			codes = codegen.PreviewCounter(tx)
+		}
+		for rowNumber := 2; ; rowNumber++ { // row 1 is the header
+			row, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			tx.SavePoint("import_row")
+			created, err := apply(tx, codes, row, job.UpsertKey)
+			job.ProcessedRows++
+			switch {
+			case err != nil:
+				tx.RollbackTo("import_row")
+				job.FailedCount++
+				if len(job.Errors) < maxStoredImportErrors {
+					rowErr := models.ImportRowError{Row: rowNumber, Message: err.Error()}
+					var re *rowError
+					if errors.As(err, &re) {
+						rowErr.Field, rowErr.Message = re.Field, re.Message
+					}
+					job.Errors = append(job.Errors, rowErr)
+				}
+			case created:
+				job.CreatedCount++
+			default:
+				job.UpdatedCount++
+			}
+
+			if job.ProcessedRows%importProgressEvery == 0 {
+				reportImportProgress(job)
+			}
+		}
+
+		if job.DryRun || (job.Mode == models.ImportModeAllOrNothing && job.FailedCount > 0) {
+			return errImportRolledBack
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		job.Committed = true
+		finishImportJob(&job, models.ImportStatusCompleted, "")
+	case errors.Is(err, errImportRolledBack) && job.DryRun:
+		finishImportJob(&job, models.ImportStatusCompleted, "Dry run, no changes were saved")
+	case errors.Is(err, errImportRolledBack):
+		finishImportJob(&job, models.ImportStatusFailed, "Rows failed validation, no changes were saved")
+	default:
+		finishImportJob(&job, models.ImportStatusFailed, err.Error())
+	}
+}
+
+// reportImportProgress publishes the counters outside the import transaction
+// so pollers can follow a running job
+func reportImportProgress(job models.ImportJob) {
+	database.DB.Model(&models.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]any{
+		"processed_rows": job.ProcessedRows,
+		"created_count":  job.CreatedCount,
+		"updated_count":  job.UpdatedCount,
+		"failed_count":   job.FailedCount,
+	})
+}
+
+func finishImportJob(job *models.ImportJob, status models.ImportStatus, message string) {
+	now := time.Now()
+	job.Status = status
+	job.FinishedAt = &now
+	if message != "" {
+		job.Message = &message
+	}
+	if err := database.DB.Save(job).Error; err != nil {
+		log.Printf("saving import job %d: %v", job.ID, err)
+	}
+}
+
+// GetImportJobs lists import jobs, newest first
+func GetImportJobs(c *gin.Context) {
+	query := database.DB.Omit("errors").Order("created_at DESC")
+	if entity := c.Query("entity_type"); entity != "" {
+		query = query.Where("entity_type = ?", entity)
+	}
+
+	var jobs []models.ImportJob
+	if err := query.Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetImportJob returns an import job with its progress and row errors
+func GetImportJob(c *gin.Context) {
+	id := c.Param("id")
+	var job models.ImportJob
+
+	if err := database.DB.First(&job, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// applyStudentRow creates or updates a student from an import row. Status is
+// not imported; it only changes through the status endpoints.
+func applyStudentRow(tx *gorm.DB, codes codegen.Counter, row importer.Row, key string) (bool, error) {
+	var student models.Student
+	created := true
+	if value := row[key]; value != "" {
+		err := tx.Where(key+" = ?", value).First(&student).Error
+		switch {
+		case err == nil:
+			created = false
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return false, err
+		}
+	} else if key != "student_code" {
+		return false, &rowError{Field: key, Message: "is required to match existing records"}
+	}
+
+	if err := setStudentFields(&student, row); err != nil {
+		return false, err
+	}
+	if student.FirstName == "" || student.LastName == "" || student.Email == "" {
+		return false, &rowError{Message: "first_name, last_name and email are required"}
+	}
+
+	if err := assignStudentCode(tx, codes, &student); err != nil {
+		return false, err
+	}
+	if !created {
+		return false, tx.Omit("gpa").Save(&student).Error
+	}
+
+	student.Status = models.StudentStatusActive
+	if err := tx.Create(&student).Error; err != nil {
+		return false, err
+	}
+	return true, recordStudentStatus(tx, student.ID, "", student.Status, nil, time.Now())
+}
+
+func setStudentFields(student *models.Student, row importer.Row) error {
+	setString(row, "student_code", &student.StudentCode)
+	setString(row, "first_name", &student.FirstName)
+	setString(row, "last_name", &student.LastName)
+	setString(row, "email", &student.Email)
+	setOptionalString(row, "phone", &student.Phone)
+	setOptionalString(row, "address", &student.Address)
+	setOptionalString(row, "major", &student.Major)
+
+	if err := setOptionalDate(row, "date_of_birth", &student.DateOfBirth); err != nil {
+		return err
+	}
+	if v, ok := row["year"]; ok && v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil || year < 1 || year > 6 {
+			return &rowError{Field: "year", Message: "must be a whole number from 1 to 6"}
+		}
+		student.Year = &year
+	}
+	if v, ok := row["gpa"]; ok && v != "" {
+		return &rowError{Field: "gpa", Message: errGPAReadOnly.Error()}
+	}
+	return nil
+}
+
+// applyEmployeeRow creates or updates an employee from an import row. Status
+// is not imported; it only changes through the lifecycle endpoints.
+func applyEmployeeRow(tx *gorm.DB, codes codegen.Counter, row importer.Row, key string) (bool, error) {
+	var employee models.Employee
+	created := true
+	if value := row[key]; value != "" {
+		err := tx.Where(key+" = ?", value).First(&employee).Error
+		switch {
+		case err == nil:
+			created = false
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return false, err
+		}
+	} else if key != "employee_id" {
+		return false, &rowError{Field: key, Message: "is required to match existing records"}
+	}
+
+	if err := setEmployeeFields(tx, &employee, row); err != nil {
+		return false, err
+	}
+	if employee.FirstName == "" || employee.LastName == "" || employee.Email == "" {
+		return false, &rowError{Message: "first_name, last_name and email are required"}
+	}
+
+	if err := assignEmployeeCode(tx, codes, &employee); err != nil {
+		if errors.Is(err, errUnknownDepartment) {
+			return false, &rowError{Field: "department_id", Message: err.Error()}
+		}
+		return false, err
+	}
+	if !created {
+		return false, tx.Save(&employee).Error
+	}
+
+	employee.Status = models.EmployeeStatusActive
+	joined := employee.JoinDate
+	if joined.IsZero() {
+		joined = time.Now()
+		employee.JoinDate = joined
+	}
+	if err := tx.Create(&employee).Error; err != nil {
+		return false, err
+	}
+	return true, recordEmployeeStatus(tx, employee.ID, "", employee.Status, nil, joined)
+}
+
+func setEmployeeFields(tx *gorm.DB, employee *models.Employee, row importer.Row) error {
+	setString(row, "first_name", &employee.FirstName)
+	setString(row, "last_name", &employee.LastName)
+	setString(row, "email", &employee.Email)
+	setOptionalString(row, "phone", &employee.Phone)
+	setOptionalString(row, "position", &employee.Position)
+	setOptionalString(row, "employee_id", &employee.EmployeeID)
+
+	if v, ok := row["department_id"]; ok && v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return &rowError{Field: "department_id", Message: "must be a number"}
+		}
+		departmentID := uint(id)
+		employee.DepartmentID = &departmentID
+	} else if name, ok := row["department"]; ok && name != "" {
+		var department models.Department
+		if err := tx.Where("name = ?", name).First(&department).Error; err != nil {
+			return &rowError{Field: "department", Message: fmt.Sprintf("department %q not found", name)}
+		}
+		employee.DepartmentID = &department.ID
+	}
+
+	if v, ok := row["shift_id"]; ok && v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return &rowError{Field: "shift_id", Message: "must be a number"}
+		}
+		shiftID := uint(id)
+		employee.ShiftID = &shiftID
+	}
+
+	var joined *time.Time
+	if err := setOptionalDate(row, "join_date", &joined); err != nil {
+		return err
+	}
+	if joined != nil {
+		employee.JoinDate = *joined
+	}
+	return nil
+}
+
+func setString(row importer.Row, field string, dst *string) {
+	if v, ok := row[field]; ok && v != "" {
+		*dst = v
+	}
+}
+
+func setOptionalString(row importer.Row, field string, dst **string) {
+	if v, ok := row[field]; ok && v != "" {
+		*dst = &v
+	}
+}
+
+// importDateLayouts are the date formats accepted in import files
+var importDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "01-02-06"}
+
+func setOptionalDate(row importer.Row, field string, dst **time.Time) error {
+	v, ok := row[field]
+	if !ok || v == "" {
+		return nil
+	}
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			*dst = &t
+			return nil
+		}
+	}
+	return &rowError{Field: field, Message: "must be a date in YYYY-MM-DD or DD/MM/YYYY format"}
+}
+
+func formBool(c *gin.Context, name string) bool {
+	v, _ := strconv.ParseBool(c.DefaultPostForm(name, c.Query(name)))
+	return v
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
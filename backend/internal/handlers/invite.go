@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/mailer"
+	"project-backend/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errInviteInvalid  = errors.New("invite is invalid or has expired")
+	errInviteAccepted = errors.New("user has already set a password")
+)
+
+// mailSender delivers invites and other account email; set by InitMailer
+var mailSender mailer.Sender = &mailer.LogSender{}
+
+// InitMailer configures the outbound email sender from MAIL_DRIVER
+func InitMailer() error {
+	sender, err := mailer.FromEnv()
+	if err != nil {
+		return err
+	}
+	mailSender = sender
+	return nil
+}
+
+// invitation is an invite created in a transaction, to be emailed once it commits
+type invitation struct {
+	user      models.User
+	token     string
+	expiresAt time.Time
+}
+
+// InviteStatus reports an invite in API responses
+type InviteStatus struct {
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Sent      bool      `json:"sent"`
+}
+
+type acceptInviteInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// inviteRequested reports whether a create request asked for a user account,
+// via ?invite=true or INVITE_ON_CREATE
+func inviteRequested(c *gin.Context) bool {
+	if v, err := strconv.ParseBool(c.Query("invite")); err == nil {
+		return v
+	}
+	return config.Bool("INVITE_ON_CREATE", false)
+}
+
+// provisionAccount creates a passwordless user linked to a new student or
+// employee, together with an invite to set the password
+func provisionAccount(tx *gorm.DB, user models.User) (*invitation, error) {
+	if err := validateUser(tx, &user); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return createInvite(tx, user)
+}
+
+// createInvite issues a new invite for user, revoking any earlier unused one
+func createInvite(tx *gorm.DB, user models.User) (*invitation, error) {
+	err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.UserTokenInvite).
+		Delete(&models.UserToken{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(time.Duration(config.Int("INVITE_TTL_HOURS", 72)) * time.Hour)
+	record := models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.UserTokenInvite,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: expires,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &invitation{user: user, token: token, expiresAt: expires}, nil
+}
+
+// sendInvite emails the invite link. A failed send is logged rather than
+// failing the request; the invite can be sent again from /users/:id/invite.
+func sendInvite(ctx context.Context, inv *invitation) InviteStatus {
+	status := InviteStatus{UserID: inv.user.ID, ExpiresAt: inv.expiresAt}
+
+	link := strings.TrimRight(config.String("APP_URL", "http://localhost:3000"), "/") +
+		"/accept-invite?token=" + url.QueryEscape(inv.token)
+	msg := mailer.Message{
+		To:      inv.user.Email,
+		Subject: "Your account invitation",
+		Body: fmt.Sprintf("Hello %s,\n\nAn account has been created for you. Set your password here:\n\n%s\n\nThis link expires on %s.\n",
+			inv.user.Name, link, inv.expiresAt.Format("2006-01-02 15:04")),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := mailSender.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send invite to user %d: %v", inv.user.ID, err)
+		return status
+	}
+	status.Sent = true
+	return status
+}
+
+// AcceptInvite sets the password of an invited user from their invite token
+// and logs them in
+func AcceptInvite(c *gin.Context) {
+	var input acceptInviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var invite models.UserToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+				auth.HashToken(input.Token), models.UserTokenInvite, time.Now()).
+			First(&invite).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInviteInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, invite.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInviteInvalid
+			}
+			return err
+		}
+		if err := tx.Model(&invite).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return setPassword(tx, &user, input.Password)
+	})
+	if err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+
+	respondLoggedIn(c, user)
+}
+
+// ResendInvite issues a fresh invite to a user who has not set a password yet
+func ResendInvite(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.PasswordHash != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errInviteAccepted.Error()})
+		return
+	}
+
+	var inv *invitation
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		inv, err = createInvite(tx, user)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sendInvite(c.Request.Context(), inv)})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// selfServiceStudentFields are the only student fields a student may change
+// about themselves; everything else, GPA and status included, stays with staff
+var selfServiceStudentFields = map[string]bool{
+	"phone":   true,
+	"address": true,
+}
+
+type myStudentInput struct {
+	Phone   *string `json:"phone" binding:"omitempty,max=20"`
+	Address *string `json:"address" binding:"omitempty,max=500"`
+}
+
+// LeaveBalance is an employee's annual leave for one calendar year, in
+// working days
+type LeaveBalance struct {
+	Year        int `json:"year"`
+	Entitlement int `json:"entitlement"`
+	Taken       int `json:"taken"`
+	Pending     int `json:"pending"`
+	Remaining   int `json:"remaining"`
+}
+
+// GetMe returns the current user with their linked Student or Employee record
+func GetMe(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if err := database.DB.Preload("Student").Preload("Employee.Department").First(user, user.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// myStudent loads the student linked to the current user, responding 404 when
+// the account has none
+func myStudent(c *gin.Context) (models.Student, bool) {
+	var student models.Student
+	user := middleware.CurrentUser(c)
+	if user.StudentID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No student record is linked to this account"})
+		return student, false
+	}
+	if err := database.DB.First(&student, *user.StudentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return student, false
+	}
+	return student, true
+}
+
+// myEmployee loads the employee linked to the current user, responding 404
+// when the account has none
+func myEmployee(c *gin.Context) (models.Employee, bool) {
+	var employee models.Employee
+	user := middleware.CurrentUser(c)
+	if user.EmployeeID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No employee record is linked to this account"})
+		return employee, false
+	}
+	if err := database.DB.Preload("Department").Preload("Shift").First(&employee, *user.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return employee, false
+	}
+	return employee, true
+}
+
+// GetMyStudent returns the current user's student record
+func GetMyStudent(c *gin.Context) {
+	student, ok := myStudent(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": student})
+}
+
+// UpdateMyStudent lets a student change their own contact details. Any other
+// field in the body is rejected rather than silently ignored.
+func UpdateMyStudent(c *gin.Context) {
+	student, ok := myStudent(c)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var forbidden []string
+	for field := range fields {
+		if !selfServiceStudentFields[field] {
+			forbidden = append(forbidden, field)
+		}
+	}
+	if len(forbidden) > 0 {
+		sort.Strings(forbidden)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Only phone and address can be changed",
+			"fields": forbidden,
+		})
+		return
+	}
+
+	var input myStudentInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]any{}
+	if _, ok := fields["phone"]; ok {
+		updates["phone"] = input.Phone
+		student.Phone = input.Phone
+	}
+	if _, ok := fields["address"]; ok {
+		updates["address"] = input.Address
+		student.Address = input.Address
+	}
+	if len(updates) > 0 {
+		if err := database.DB.WithContext(c.Request.Context()).Model(&student).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": student})
+}
+
+// GetMyEmployee returns the current user's employee record
+func GetMyEmployee(c *gin.Context) {
+	employee, ok := myEmployee(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": employee})
+}
+
+// GetMyAttendance returns the current employee's attendance records
+func GetMyAttendance(c *gin.Context) {
+	employee, ok := myEmployee(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("employee_id = ?", employee.ID).Order("date DESC")
+	if from := c.Query("from"); from != "" {
+		query = query.Where("date >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("date <= ?", to)
+	}
+
+	var records []models.AttendanceRecord
+	if err := query.Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"count": len(records),
+	})
+}
+
+// GetMyLeaveBalance returns the current employee's annual leave balance for
+// ?year= (default this year). Only working days count, so weekends and
+// holidays inside a leave request are not deducted.
+func GetMyLeaveBalance(c *gin.Context) {
+	employee, ok := myEmployee(c)
+	if !ok {
+		return
+	}
+
+	year := time.Now().Year()
+	if v := c.Query("year"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a number"})
+			return
+		}
+		year = parsed
+	}
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	var requests []models.LeaveRequest
+	err := database.DB.
+		Where("employee_id = ? AND type = ? AND status IN ?", employee.ID, "annual",
+			[]models.LeaveStatus{models.LeaveStatusApproved, models.LeaveStatusPending}).
+		Where("start_date <= ? AND end_date >= ?", end, start).
+		Find(&requests).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	holidays, err := holidayDates(database.DB, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance := LeaveBalance{Year: year, Entitlement: config.Int("LEAVE_ANNUAL_DAYS", 12)}
+	for _, request := range requests {
+		from, to := truncateToDate(request.StartDate), truncateToDate(request.EndDate)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		days := 0
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			if isWorkingDay(day, holidays) {
+				days++
+			}
+		}
+		if request.Status == models.LeaveStatusApproved {
+			balance.Taken += days
+		} else {
+			balance.Pending += days
+		}
+	}
+	balance.Remaining = balance.Entitlement - balance.Taken
+
+	c.JSON(http.StatusOK, gin.H{"data": balance})
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// overtimePolicy holds the detection thresholds and pay multipliers for overtime
+type overtimePolicy struct {
+	MinMinutes      int
+	RoundingMinutes int
+	Multipliers     map[models.DayType]float64
+}
+
+func loadOvertimePolicy() overtimePolicy {
+	return overtimePolicy{
+		MinMinutes:      config.Int("OVERTIME_MIN_MINUTES", 30),
+		RoundingMinutes: config.Int("OVERTIME_ROUNDING_MINUTES", 15),
+		Multipliers: map[models.DayType]float64{
+			models.DayTypeWeekday: config.Float("OVERTIME_WEEKDAY_MULTIPLIER", 1.5),
+			models.DayTypeWeekend: config.Float("OVERTIME_WEEKEND_MULTIPLIER", 2.0),
+			models.DayTypeHoliday: config.Float("OVERTIME_HOLIDAY_MULTIPLIER", 3.0),
+		},
+	}
+}
+
+// round applies the threshold and rounds minutes down to the rounding
+// increment. An early check-out has no overtime even with no threshold.
+func (p overtimePolicy) round(minutes int) int {
+	if minutes <= 0 || minutes < p.MinMinutes {
+		return 0
+	}
+	if p.RoundingMinutes > 1 {
+		minutes -= minutes % p.RoundingMinutes
+	}
+	return minutes
+}
+
+// dayTypeFor classifies a date as holiday, weekend or weekday
+func dayTypeFor(tx *gorm.DB, date time.Time) (models.DayType, error) {
+	var count int64
+	if err := tx.Model(&models.Holiday{}).Where("date = ?", date.Format("2006-01-02")).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return models.DayTypeHoliday, nil
+	}
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return models.DayTypeWeekend, nil
+	}
+	return models.DayTypeWeekday, nil
+}
+
+// detectOvertime compares the check-out with the shift end and files a pending
+// overtime request for the record when the worked surplus passes the threshold
+func detectOvertime(tx *gorm.DB, shift *models.Shift, record *models.AttendanceRecord) error {
+	record.OvertimeMinutes = 0
+	policy := loadOvertimePolicy()
+	if shift != nil && record.CheckOut != nil {
+		end, err := shiftClock(*record.CheckOut, shift.EndTime)
+		if err != nil {
+			return err
+		}
+		record.OvertimeMinutes = policy.round(int(record.CheckOut.Sub(end).Minutes()))
+	}
+
+	var request models.OvertimeRequest
+	err := tx.Where("attendance_record_id = ?", record.ID).First(&request).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if request.ID != 0 && request.Status != models.OvertimeStatusPending {
+		// Already reviewed, keep the manager's decision
+		return nil
+	}
+
+	if record.OvertimeMinutes == 0 {
+		// A correction may have removed overtime that was detected earlier
+		if request.ID != 0 {
+			return tx.Unscoped().Delete(&request).Error
+		}
+		return nil
+	}
+
+	dayType, err := dayTypeFor(tx, record.Date)
+	if err != nil {
+		return err
+	}
+
+	request.EmployeeID = record.EmployeeID
+	request.AttendanceRecordID = &record.ID
+	request.Date = record.Date
+	request.Minutes = record.OvertimeMinutes
+	request.DayType = dayType
+	request.Multiplier = policy.Multipliers[dayType]
+	request.Status = models.OvertimeStatusPending
+	return tx.Save(&request).Error
+}
+
+// approvalChainQuery walks from a department up to the root, nearest first
+const approvalChainQuery = `
+WITH RECURSIVE chain AS (
+	SELECT id, parent_id, manager_id, 0 AS depth FROM departments WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT d.id, d.parent_id, d.manager_id, c.depth + 1
+	FROM departments d JOIN chain c ON d.id = c.parent_id WHERE d.deleted_at IS NULL
+)
+SELECT id, manager_id FROM chain ORDER BY depth`
+
+// canApprove reports whether approverID manages the employee's department,
+// directly or through one of its parent departments. Nobody approves their
+// own requests: a manager's requests go to the manager of the department
+// above the highest one they run.
+func canApprove(approverID uint, employee models.Employee) (bool, error) {
+	if employee.DepartmentID == nil || employee.ID == approverID {
+		return false, nil
+	}
+	var chain []struct {
+		ID        uint
+		ManagerID *uint
+	}
+	if err := database.DB.Raw(approvalChainQuery, *employee.DepartmentID).Scan(&chain).Error; err != nil {
+		return false, err
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].ManagerID != nil && *chain[i].ManagerID == employee.ID {
+			chain = chain[i+1:]
+			break
+		}
+	}
+	for _, department := range chain {
+		if department.ManagerID != nil && *department.ManagerID == approverID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// visibleRequests limits a query over employee requests to those the
+// signed-in user may see: all of them for admin and HR, otherwise their own
+// and those of the employees in the departments they manage
+func visibleRequests(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	user := middleware.CurrentUser(c)
+	if user.Role == models.UserRoleAdmin || user.Role == models.UserRoleHR {
+		return query, true
+	}
+	if user.EmployeeID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account is not linked to an employee"})
+		return nil, false
+	}
+	managed, err := managedDepartmentIDs(*user.EmployeeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(managed) == 0 {
+		return query.Where("employee_id = ?", *user.EmployeeID), true
+	}
+	return query.Where("(employee_id = ? OR employee_id IN (SELECT id FROM employees WHERE department_id IN ?))",
+		*user.EmployeeID, managed), true
+}
+
+// GetOvertimeRequests retrieves overtime requests filtered by employee and
+// status. Managers see their departments' requests, other employees their own.
+func GetOvertimeRequests(c *gin.Context) {
+	query, ok := visibleRequests(c, database.DB.Preload("Employee").Preload("Approver").Order("date DESC"))
+	if !ok {
+		return
+	}
+
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []models.OvertimeRequest
+	if err := query.Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  requests,
+		"count": len(requests),
+	})
+}
+
+type createOvertimeInput struct {
+	EmployeeID *uint     `json:"employee_id"`
+	Date       time.Time `json:"date" binding:"required"`
+	Minutes    int       `json:"minutes" binding:"required,gt=0"`
+	Reason     *string   `json:"reason"`
+}
+
+// requestEmployeeID picks whose request is being filed: admin and HR may file
+// for anyone, everyone else only for the employee record of their account
+func requestEmployeeID(c *gin.Context, requested *uint) (uint, bool) {
+	user := middleware.CurrentUser(c)
+	if user.Role == models.UserRoleAdmin || user.Role == models.UserRoleHR {
+		if requested != nil {
+			return *requested, true
+		}
+	}
+	if user.EmployeeID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account is not linked to an employee"})
+		return 0, false
+	}
+	if requested != nil && *requested != *user.EmployeeID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only file requests for yourself"})
+		return 0, false
+	}
+	return *user.EmployeeID, true
+}
+
+// CreateOvertimeRequest files a manual overtime request, e.g. for work done off-site
+func CreateOvertimeRequest(c *gin.Context) {
+	var input createOvertimeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	employeeID, ok := requestEmployeeID(c, input.EmployeeID)
+	if !ok {
+		return
+	}
+
+	var employee models.Employee
+	if err := database.DB.First(&employee, employeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	date := truncateToDate(input.Date)
+	dayType, err := dayTypeFor(database.DB, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := loadOvertimePolicy()
+	request := models.OvertimeRequest{
+		EmployeeID: employee.ID,
+		Date:       date,
+		Minutes:    policy.round(input.Minutes),
+		DayType:    dayType,
+		Multiplier: policy.Multipliers[dayType],
+		Reason:     input.Reason,
+		Status:     models.OvertimeStatusPending,
+	}
+	if request.Minutes == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Overtime is below the minimum threshold"})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Create(&request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": request})
+}
+
+type reviewInput struct {
+	Note *string `json:"note"`
+}
+
+// bindOptionalJSON binds a request body whose fields are all optional, so an
+// empty body counts as {}
+func bindOptionalJSON(c *gin.Context, obj any) error {
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// reviewerID returns the employee record of the signed-in user, who reviews
+// requests as a department manager
+func reviewerID(c *gin.Context) (uint, bool) {
+	user := middleware.CurrentUser(c)
+	if user.EmployeeID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the department manager can review this request"})
+		return 0, false
+	}
+	return *user.EmployeeID, true
+}
+
+// withDeletedEmployee preloads the employee even when it has been soft
+// deleted, since requests filed before the deletion can still be reviewed
+func withDeletedEmployee(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// ApproveOvertimeRequest approves a pending overtime request
+func ApproveOvertimeRequest(c *gin.Context) {
+	reviewOvertimeRequest(c, models.OvertimeStatusApproved)
+}
+
+// RejectOvertimeRequest rejects a pending overtime request
+func RejectOvertimeRequest(c *gin.Context) {
+	reviewOvertimeRequest(c, models.OvertimeStatusRejected)
+}
+
+func reviewOvertimeRequest(c *gin.Context, status models.OvertimeStatus) {
+	id := c.Param("id")
+	var input reviewInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	approverID, ok := reviewerID(c)
+	if !ok {
+		return
+	}
+
+	var request models.OvertimeRequest
+	if err := database.DB.Preload("Employee", withDeletedEmployee).First(&request, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Overtime request not found"})
+		return
+	}
+	if request.Status != models.OvertimeStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Overtime request has already been reviewed"})
+		return
+	}
+	if request.Employee == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Employee no longer exists"})
+		return
+	}
+
+	allowed, err := canApprove(approverID, *request.Employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the department manager can review this request"})
+		return
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.ApproverID = &approverID
+	request.ReviewedAt = &now
+	request.ReviewNote = input.Note
+	// Only the first of two concurrent reviews may decide
+	result := database.DB.WithContext(c.Request.Context()).Model(&request).
+		Where("status = ?", models.OvertimeStatusPending).
+		Select("status", "approver_id", "reviewed_at", "review_note").
+		Updates(&request)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Overtime request has already been reviewed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/mailer"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPasswordPolicy = errors.New("password rejected")
+	errResetInvalid   = errors.New("reset link is invalid or has expired")
+)
+
+// passwordPolicy is loaded by ValidateAuthConfig
+var passwordPolicy *auth.PasswordPolicy
+
+// lockoutPolicy locks an account after MaxAttempts failed logins in a row.
+// Each further failure doubles the lock, from Base up to Max.
+type lockoutPolicy struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+func loadLockoutPolicy() lockoutPolicy {
+	return lockoutPolicy{
+		MaxAttempts: config.Int("LOGIN_MAX_ATTEMPTS", 5),
+		Base:        time.Duration(config.Int("LOGIN_LOCKOUT_SECONDS", 60)) * time.Second,
+		Max:         time.Duration(config.Int("LOGIN_LOCKOUT_MAX_SECONDS", 3600)) * time.Second,
+	}
+}
+
+// lockFor returns how long to lock an account after failures consecutive
+// failed logins, or 0 while it is still below the limit
+func (p lockoutPolicy) lockFor(failures int) time.Duration {
+	if failures < p.MaxAttempts {
+		return 0
+	}
+	lock := float64(p.Base) * math.Pow(2, float64(failures-p.MaxAttempts))
+	if lock > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(lock)
+}
+
+type forgotPasswordInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type resetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type changePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// setPassword checks password against the policy and stores it. Changing the
+// password also clears any lockout, revokes outstanding reset links and
+// revokes access tokens issued before now.
+func setPassword(tx *gorm.DB, user *models.User, password string) error {
+	if err := passwordPolicy.Check(password, user.Email, user.Name); err != nil {
+		return fmt.Errorf("%w: %v", errPasswordPolicy, err)
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PasswordHash = &hash
+	user.PasswordChangedAt = &now
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	err = tx.Model(user).Updates(map[string]any{
+		"password_hash":       hash,
+		"password_changed_at": now,
+		"failed_logins":       0,
+		"locked_until":        nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.UserTokenPasswordReset).
+		Delete(&models.UserToken{}).Error
+}
+
+// recordFailedLogin counts a failed login and locks the account once the
+// lockout policy says so. The count is incremented in SQL so that parallel
+// attempts are all counted.
+func recordFailedLogin(tx *gorm.DB, user *models.User) error {
+	err := tx.Model(user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_logins"}}}).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error
+	if err != nil {
+		return err
+	}
+	lock := loadLockoutPolicy().lockFor(user.FailedLogins)
+	if lock == 0 {
+		return nil
+	}
+	until := time.Now().Add(lock)
+	user.LockedUntil = &until
+	return tx.Model(user).UpdateColumn("locked_until", until).Error
+}
+
+// recordSuccessfulLogin clears the failed login count
+func recordSuccessfulLogin(tx *gorm.DB, user *models.User) error {
+	if user.FailedLogins == 0 && user.LockedUntil == nil {
+		return nil
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	return tx.Model(user).UpdateColumns(map[string]any{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+// respondLocked reports a locked account with the seconds left in Retry-After
+func respondLocked(c *gin.Context, until time.Time) {
+	seconds := int(math.Ceil(time.Until(until).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusLocked, gin.H{
+		"error":        "Account is temporarily locked after too many failed logins",
+		"locked_until": until,
+	})
+}
+
+func respondPasswordError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errPasswordPolicy), errors.Is(err, errResetInvalid), errors.Is(err, errInviteInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the email belongs to an account.
+func ForgotPassword(c *gin.Context) {
+	var input forgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := gin.H{"message": "If the email belongs to an account, a reset link has been sent"}
+
+	var user models.User
+	if err := database.DB.Where("lower(email) = ?", strings.ToLower(strings.TrimSpace(input.Email))).First(&user).Error; err != nil {
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := auth.GenerateSignedToken(models.UserTokenPasswordReset, config.String("JWT_SECRET", ""))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expires := time.Now().Add(time.Duration(config.Int("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute)
+
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Only the latest link works
+		err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.UserTokenPasswordReset).
+			Delete(&models.UserToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.UserTokenPasswordReset,
+			TokenHash: auth.HashToken(token),
+			ExpiresAt: expires,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := strings.TrimRight(config.String("APP_URL", "http://localhost:3000"), "/") +
+		"/reset-password?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nUse this link to choose a new password:\n\n%s\n\nThe link expires on %s. If you did not ask for it, ignore this email.\n",
+			user.Name, link, expires.Format("2006-01-02 15:04")),
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	if err := mailSender.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send password reset to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword sets a new password from a reset link. Each link works once.
+func ResetPassword(c *gin.Context) {
+	var input resetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !auth.VerifySignedToken(input.Token, models.UserTokenPasswordReset, config.String("JWT_SECRET", "")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errResetInvalid.Error()})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var reset models.UserToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+				auth.HashToken(input.Token), models.UserTokenPasswordReset, time.Now()).
+			First(&reset).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errResetInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, reset.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errResetInvalid
+			}
+			return err
+		}
+		if err := tx.Model(&reset).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return setPassword(tx, &user, input.Password)
+	})
+	if err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// ChangeMyPassword changes the current user's password after checking the
+// current one. Other sessions are signed out, so a new token is returned.
+func ChangeMyPassword(c *gin.Context) {
+	var input changePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if !auth.CheckPassword(user.PasswordHash, input.CurrentPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, user, input.NewPassword)
+	})
+	if err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+
+	token, expires, err := issueToken(*user, middleware.CurrentClaims(c).AMR)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": expires,
+	})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/export"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimesheetRow is one employee's attendance summary for a month
+type TimesheetRow struct {
+	EmployeeID     uint    `json:"employee_id"`
+	EmployeeCode   *string `json:"employee_code"`
+	FirstName      string  `json:"first_name"`
+	LastName       string  `json:"last_name"`
+	DepartmentName *string `json:"department_name"`
+	DaysPresent    int     `json:"days_present"`
+	LateCount      int     `json:"late_count"`
+	LateMinutes    int     `json:"late_minutes"`
+	OvertimeHours  float64 `json:"overtime_hours"`
+	WeightedHours  float64 `json:"weighted_overtime_hours"`
+	LeaveDays      int     `json:"leave_days"`
+}
+
+var timesheetColumns = []string{
+	"employee_id", "employee_code", "first_name", "last_name", "department_name",
+	"days_present", "late_count", "late_minutes", "overtime_hours", "weighted_overtime_hours", "leave_days",
+}
+
+func (r TimesheetRow) values() []any {
+	return []any{
+		r.EmployeeID, r.EmployeeCode, r.FirstName, r.LastName, r.DepartmentName,
+		r.DaysPresent, r.LateCount, r.LateMinutes, r.OvertimeHours, r.WeightedHours, r.LeaveDays,
+	}
+}
+
+// timesheetQuery counts leave in working days, weekdays that are not
+// holidays, as the leave balance does
+const timesheetQuery = `
+SELECT e.id AS employee_id,
+	e.employee_id AS employee_code,
+	e.first_name,
+	e.last_name,
+	d.name AS department_name,
+	COUNT(a.id) FILTER (WHERE a.check_in IS NOT NULL) AS days_present,
+	COUNT(a.id) FILTER (WHERE a.late_minutes > 0) AS late_count,
+	COALESCE(SUM(a.late_minutes), 0) AS late_minutes,
+	COALESCE(o.hours, 0) AS overtime_hours,
+	COALESCE(o.weighted_hours, 0) AS weighted_hours,
+	(SELECT COUNT(*)
+		FROM leave_requests l
+		CROSS JOIN LATERAL generate_series(GREATEST(l.start_date, @month_start::date),
+			LEAST(l.end_date, @month_end::date), interval '1 day') AS g(day)
+		WHERE l.employee_id = e.id AND l.status = 'approved' AND l.deleted_at IS NULL
+			AND l.start_date <= @month_end AND l.end_date >= @month_start
+			AND EXTRACT(ISODOW FROM g.day) < 6
+			AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = g.day::date)) AS leave_days
+FROM employees e
+LEFT JOIN departments d ON d.id = e.department_id
+LEFT JOIN (
+	SELECT employee_id,
+		ROUND(SUM(minutes) / 60.0, 2) AS hours,
+		ROUND(SUM(minutes * multiplier) / 60.0, 2) AS weighted_hours
+	FROM overtime_requests
+	WHERE status = 'approved' AND deleted_at IS NULL
+		AND date >= @month_start AND date <= @month_end
+	GROUP BY employee_id
+) o ON o.employee_id = e.id
+LEFT JOIN attendance_records a ON a.employee_id = e.id
+	AND a.date >= @month_start AND a.date <= @month_end
+WHERE e.deleted_at IS NULL %s
+GROUP BY e.id, d.name, o.hours, o.weighted_hours
+ORDER BY e.id`
+
+// GetTimesheetReport streams the monthly timesheet as JSON, CSV or XLSX
+func GetTimesheetReport(c *gin.Context) {
+	month, err := time.Parse("2006-01", c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month parameter is required in YYYY-MM format"})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatJSON)
+	if !export.IsSupported(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of json, jsonl, csv, xlsx"})
+		return
+	}
+
+	params := map[string]any{
+		"month_start": month.Format("2006-01-02"),
+		"month_end":   month.AddDate(0, 1, -1).Format("2006-01-02"),
+	}
+	filter := ""
+	if departmentID := c.Query("department_id"); departmentID != "" {
+		filter = "AND e.department_id = @department_id"
+		params["department_id"] = departmentID
+	}
+
+	rows, err := database.DB.Raw(fmt.Sprintf(timesheetQuery, filter), params).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", export.ContentType(format))
+	if format != export.FormatJSON && format != export.FormatJSONL {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="timesheet-%s.%s"`, month.Format("2006-01"), format))
+	}
+
+	writer, err := export.NewWriter(format, c.Writer, export.Options{BOM: c.Query("bom") == "true"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := writer.WriteHeader(timesheetColumns); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Rows are written as they are scanned so large departments never sit in memory
+	for rows.Next() {
+		var row TimesheetRow
+		if err := database.DB.ScanRows(rows, &row); err != nil {
+			c.Error(err)
+			return
+		}
+		if err := writer.WriteRow(row.values()); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"project-backend/internal/database"
+	"project-backend/internal/search"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResult is one person matched by GET /search. Highlights holds the
+// matched fields with the matching parts wrapped in <mark> tags.
+type SearchResult struct {
+	Type       string            `json:"type"`
+	ID         uint              `json:"id"`
+	Code       *string           `json:"code"`
+	Name       string            `json:"name"`
+	Email      string            `json:"email"`
+	Phone      *string           `json:"phone"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type searchRow struct {
+	ID        uint
+	Code      *string
+	FirstName string
+	LastName  string
+	Email     string
+	Phone     *string
+	Score     float64
+}
+
+// searchTarget describes how one table is searched
+type searchTarget struct {
+	Type     string
+	Table    string
+	Code     string
+	Document string
+}
+
+var searchTargets = []searchTarget{
+	{Type: "student", Table: "students", Code: "student_code", Document: database.StudentSearchDocument},
+	{Type: "employee", Table: "employees", Code: "employee_id", Document: database.EmployeeSearchDocument},
+}
+
+// Search finds students and employees by name, email, code or phone,
+// ignoring case and Vietnamese diacritics. Every word of ?q= must match;
+// with ?prefix=true words only need to match the start of a word, for
+// type-ahead. ?type= is students, employees or all (default).
+func Search(c *gin.Context) {
+	tokens := search.Tokens(c.Query("q"))
+	if len(tokens) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	var targets []searchTarget
+	switch c.DefaultQuery("type", "all") {
+	case "students":
+		targets = searchTargets[:1]
+	case "employees":
+		targets = searchTargets[1:]
+	case "all":
+		targets = searchTargets
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be students, employees or all"})
+		return
+	}
+
+	prefix := c.Query("prefix") == "true"
+	results := []SearchResult{}
+	for _, target := range targets {
+		found, err := searchPeople(target, tokens, prefix, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  results,
+		"count": len(results),
+	})
+}
+
+// searchPeople runs the trigram-indexed match against one table. Ranking is
+// word similarity to the whole query, boosted for an exact code or email
+// and for names starting with the query.
+func searchPeople(target searchTarget, tokens []string, prefix bool, limit int) ([]SearchResult, error) {
+	query := strings.Join(tokens, " ")
+	name := "f_unaccent(lower(first_name || ' ' || last_name))"
+	score := fmt.Sprintf(`word_similarity(@query, %s)
+		+ CASE WHEN lower(coalesce(%s, '')) = @query THEN 1 ELSE 0 END
+		+ CASE WHEN lower(email) = @query THEN 1 ELSE 0 END
+		+ CASE WHEN %s LIKE @name_prefix THEN 0.5 ELSE 0 END`,
+		target.Document, target.Code, name)
+
+	db := database.DB.Table(target.Table).
+		Select(fmt.Sprintf("id, %s AS code, first_name, last_name, email, phone, %s AS score", target.Code, score),
+			map[string]any{"query": query, "name_prefix": escapeLike(query) + "%"}).
+		Where("deleted_at IS NULL")
+	for _, token := range tokens {
+		if prefix {
+			// \m anchors the token to the start of a word
+			db = db.Where(target.Document+" ~ ?", `\m`+regexp.QuoteMeta(token))
+		} else {
+			db = db.Where(target.Document+" LIKE ?", "%"+escapeLike(token)+"%")
+		}
+	}
+
+	var rows []searchRow
+	if err := db.Order("score DESC, id").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		result := SearchResult{
+			Type:       target.Type,
+			ID:         row.ID,
+			Code:       row.Code,
+			Name:       row.FirstName + " " + row.LastName,
+			Email:      row.Email,
+			Phone:      row.Phone,
+			Score:      row.Score,
+			Highlights: map[string]string{},
+		}
+		fields := map[string]string{"name": result.Name, "email": row.Email}
+		if row.Code != nil {
+			fields["code"] = *row.Code
+		}
+		if row.Phone != nil {
+			fields["phone"] = *row.Phone
+		}
+		for field, value := range fields {
+			if marked, ok := search.Highlight(value, tokens, prefix); ok {
+				result.Highlights[field] = marked
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
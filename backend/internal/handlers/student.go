@@ -2,17 +2,66 @@ package handlers
 
 import (
 	"net/http"
+	"project-backend/internal/codegen"
 	"project-backend/internal/database"
+	"project-backend/internal/filter"
 	"project-backend/internal/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetStudents retrieves all students
+// studentFilterFields are the fields ?filter= may use on students
+var studentFilterFields = filter.Fields{
+	"student_code":  {Column: "students.student_code"},
+	"code":          {Column: "students.student_code"},
+	"first_name":    {Column: "students.first_name"},
+	"last_name":     {Column: "students.last_name"},
+	"email":         {Column: "students.email"},
+	"phone":         {Column: "students.phone"},
+	"major":         {Column: "students.major"},
+	"year":          {Column: "students.year", Type: filter.Number},
+	"gpa":           {Column: "students.gpa", Type: filter.Number},
+	"status":        {Column: "students.status", Type: filter.Enum, Values: []string{"active", "inactive", "graduated", "suspended", "withdrawn"}},
+	"date_of_birth": {Column: "students.date_of_birth", Type: filter.Date},
+	"created_at":    {Column: "students.created_at", Type: filter.Date},
+}
+
+// studentFilters builds the ?major=, ?status= and ?filter= filters shared by
+// the student list and export endpoints
+func studentFilters(c *gin.Context) (func(*gorm.DB) *gorm.DB, error) {
+	condition, args, err := filter.Compile(c.Query("filter"), studentFilterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if major := c.Query("major"); major != "" {
+			db = db.Where("students.major = ?", major)
+		}
+		if status := c.Query("status"); status != "" {
+			db = db.Where("students.status = ?", status)
+		}
+		if condition != "" {
+			db = db.Where(condition, args...)
+		}
+		return db
+	}, nil
+}
+
+// GetStudents retrieves all students, optionally filtered by major, status
+// and a ?filter= expression
 func GetStudents(c *gin.Context) {
 	var students []models.Student
-	
-	result := database.DB.Find(&students)
+
+	filters, err := studentFilters(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	result := database.DB.Scopes(filters).Find(&students)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
@@ -38,21 +87,60 @@ func GetStudent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": student})
 }
 
-// CreateStudent creates a new student
+// CreateStudent creates a new student, and an invited user account when asked
 func CreateStudent(c *gin.Context) {
 	var student models.Student
 	if err := c.ShouldBindJSON(&student); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if student.GPA != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGPAReadOnly.Error()})
+		return
+	}
 
-	result := database.DB.Create(&student)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// New students start active unless explicitly created as inactive
+	if student.Status == "" {
+		student.Status = models.StudentStatusActive
+	}
+	if student.Status != models.StudentStatusActive && student.Status != models.StudentStatusInactive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New students must be active or inactive"})
+		return
+	}
+
+	var inv *invitation
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := assignStudentCode(tx, codegen.DBCounter(tx), &student); err != nil {
+			return err
+		}
+		if err := tx.Create(&student).Error; err != nil {
+			return err
+		}
+		if err := recordStudentStatus(tx, student.ID, "", student.Status, nil, time.Now()); err != nil {
+			return err
+		}
+		if !inviteRequested(c) {
+			return nil
+		}
+		var err error
+		inv, err = provisionAccount(tx, models.User{
+			Name:      student.FirstName + " " + student.LastName,
+			Email:     student.Email,
+			Role:      models.UserRoleStudent,
+			StudentID: &student.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondCodeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"data": student})
+	response := gin.H{"data": student}
+	if inv != nil {
+		response["invite"] = sendInvite(c.Request.Context(), inv)
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateStudent updates an existing student
@@ -66,14 +154,35 @@ func UpdateStudent(c *gin.Context) {
 		return
 	}
 
-	// Bind updated data
+	previousStatus := student.Status
+
+	// Bind updated data; GPA is cleared first so a client-supplied value shows
+	gpa := student.GPA
+	student.GPA = nil
 	if err := c.ShouldBindJSON(&student); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if student.GPA != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGPAReadOnly.Error()})
+		return
+	}
+	student.GPA = gpa
+
+	// Status only changes through the status endpoints so history is kept
+	if student.Status != previousStatus {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status cannot be changed here; use the suspend, reinstate, graduate or withdraw endpoints"})
+		return
+	}
+
+	// StudentCode stays unique; clearing it draws a fresh generated code
+	if err := assignStudentCode(database.DB.WithContext(c.Request.Context()), codegen.DBCounter(database.DB.WithContext(c.Request.Context())), &student); err != nil {
+		respondCodeError(c, err)
+		return
+	}
 
-	// Update student
-	if err := database.DB.Save(&student).Error; err != nil {
+	// Update student; GPA is only ever written by recomputeStudentGPA
+	if err := database.DB.WithContext(c.Request.Context()).Omit("gpa").Save(&student).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -92,7 +201,7 @@ func DeleteStudent(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&student).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&student).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxStudyYears = 6
+
+var (
+	errRolloverDone   = errors.New("rollover already ran for this academic year")
+	errRolloverDryRun = errors.New("rollover dry run")
+)
+
+// studentTransition describes one status action and the states it may start from
+type studentTransition struct {
+	From          []string
+	To            string
+	RequireReason bool
+}
+
+var studentTransitions = map[string]studentTransition{
+	"suspend": {
+		From:          []string{models.StudentStatusActive},
+		To:            models.StudentStatusSuspended,
+		RequireReason: true,
+	},
+	"reinstate": {
+		From: []string{models.StudentStatusSuspended, models.StudentStatusInactive},
+		To:   models.StudentStatusActive,
+	},
+	"graduate": {
+		From: []string{models.StudentStatusActive},
+		To:   models.StudentStatusGraduated,
+	},
+	"withdraw": {
+		From:          []string{models.StudentStatusActive, models.StudentStatusSuspended, models.StudentStatusInactive},
+		To:            models.StudentStatusWithdrawn,
+		RequireReason: true,
+	},
+}
+
+func (t studentTransition) allows(status string) bool {
+	return containsString(t.From, status)
+}
+
+type studentTransitionInput struct {
+	Reason        *string    `json:"reason"`
+	EffectiveDate *time.Time `json:"effective_date"`
+}
+
+// graduationRequirements are the earned credits and cumulative GPA a student
+// needs to graduate
+type graduationRequirements struct {
+	Credits int
+	MinGPA  float64
+}
+
+func loadGraduationRequirements() graduationRequirements {
+	return graduationRequirements{
+		Credits: config.Int("GRADUATION_CREDITS", 120),
+		MinGPA:  config.Float("GRADUATION_MIN_GPA", 2.0),
+	}
+}
+
+func (r graduationRequirements) met(summary gpaSummary) bool {
+	return summary.EarnedCredits >= r.Credits && summary.GPA != nil && *summary.GPA >= r.MinGPA
+}
+
+// promotionRequirements are the earned credits a student needs per completed
+// study year to move up a year in the rollover
+type promotionRequirements struct {
+	CreditsPerYear int
+}
+
+func loadPromotionRequirements() promotionRequirements {
+	return promotionRequirements{
+		CreditsPerYear: config.Int("PROMOTION_CREDITS_PER_YEAR", 24),
+	}
+}
+
+func (r promotionRequirements) met(year int, summary gpaSummary) bool {
+	return summary.EarnedCredits >= year*r.CreditsPerYear
+}
+
+// SuspendStudent moves an active student to suspended
+func SuspendStudent(c *gin.Context) {
+	transitionStudent(c, "suspend")
+}
+
+// ReinstateStudent returns a suspended or inactive student to active
+func ReinstateStudent(c *gin.Context) {
+	transitionStudent(c, "reinstate")
+}
+
+// GraduateStudent graduates an active student who meets the credit and GPA requirements
+func GraduateStudent(c *gin.Context) {
+	transitionStudent(c, "graduate")
+}
+
+// WithdrawStudent records that a student has left without graduating
+func WithdrawStudent(c *gin.Context) {
+	transitionStudent(c, "withdraw")
+}
+
+func transitionStudent(c *gin.Context, action string) {
+	transition := studentTransitions[action]
+	id := c.Param("id")
+
+	var input studentTransitionInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if transition.RequireReason && (input.Reason == nil || *input.Reason == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required to " + action + " a student"})
+		return
+	}
+
+	var student models.Student
+	if err := database.DB.First(&student, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+	if !transition.allows(student.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Cannot " + action + " a student who is " + student.Status,
+			"status": student.Status,
+		})
+		return
+	}
+
+	if transition.To == models.StudentStatusGraduated {
+		var summary gpaSummary
+		if err := database.DB.Raw(cumulativeGPAQuery, student.ID).Scan(&summary).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if requirements := loadGraduationRequirements(); !requirements.met(summary) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":            "Graduation requirements not met",
+				"earned_credits":   summary.EarnedCredits,
+				"gpa":              summary.GPA,
+				"required_credits": requirements.Credits,
+				"required_gpa":     requirements.MinGPA,
+			})
+			return
+		}
+	}
+
+	effective := time.Now()
+	if input.EffectiveDate != nil {
+		effective = *input.EffectiveDate
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return setStudentStatus(tx, &student, transition.To, input.Reason, effective)
+	})
+	if errors.Is(err, errStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Student status was changed by another request"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": student})
+}
+
+// setStudentStatus changes a student's status and appends it to the history.
+// A student who is no longer active gives up their seats and waitlist places.
+func setStudentStatus(tx *gorm.DB, student *models.Student, status string, reason *string, effective time.Time) error {
+	from := student.Status
+	// Only one of two concurrent transitions may start from this status
+	result := tx.Model(student).Where("status = ?", from).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errStatusChanged
+	}
+	if err := recordStudentStatus(tx, student.ID, from, status, reason, effective); err != nil {
+		return err
+	}
+	student.Status = status
+	if status == models.StudentStatusActive {
+		return nil
+	}
+	return dropLiveEnrollments(tx, student.ID)
+}
+
+// dropLiveEnrollments drops the student's enrolled and waitlisted
+// enrollments, promoting from the waitlist into each seat freed
+func dropLiveEnrollments(tx *gorm.DB, studentID uint) error {
+	var enrollments []models.Enrollment
+	err := tx.Where("student_id = ? AND status IN ?", studentID, liveEnrollmentStatuses).
+		Order("course_id, semester_id").
+		Find(&enrollments).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, enrollment := range enrollments {
+		if _, err := lockCourse(tx, enrollment.CourseID); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Enrollment{}).
+			Where("id = ? AND status = ?", enrollment.ID, enrollment.Status).
+			Updates(map[string]any{"status": models.EnrollmentStatusDropped, "dropped_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 && enrollment.Status == models.EnrollmentStatusEnrolled {
+			if err := fillFromWaitlist(tx, enrollment.CourseID, enrollment.SemesterID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordStudentStatus appends a status change to the student's history
+func recordStudentStatus(tx *gorm.DB, studentID uint, from, to string, reason *string, effective time.Time) error {
+	return tx.Create(&models.StudentStatusHistory{
+		StudentID:     studentID,
+		FromStatus:    from,
+		ToStatus:      to,
+		Reason:        reason,
+		EffectiveDate: truncateToDate(effective),
+	}).Error
+}
+
+// GetStudentStatusHistory lists the status transitions of a student
+func GetStudentStatusHistory(c *gin.Context) {
+	id := c.Param("id")
+	var student models.Student
+	if err := database.DB.Unscoped().First(&student, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	var history []models.StudentStatusHistory
+	err := database.DB.Where("student_id = ?", student.ID).Order("created_at DESC, id DESC").Find(&history).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   history,
+		"count":  len(history),
+		"status": student.Status,
+	})
+}
+
+type rolloverInput struct {
+	AcademicYear  string     `json:"academic_year" binding:"required"`
+	EffectiveDate *time.Time `json:"effective_date"`
+	DryRun        bool       `json:"dry_run"`
+}
+
+type rolloverOutcome struct {
+	StudentID   uint   `json:"student_id"`
+	StudentCode string `json:"student_code"`
+	Action      string `json:"action"` // graduated, promoted or flagged
+	Reason      string `json:"reason,omitempty"`
+	Year        *int   `json:"year"`
+}
+
+// RunStudentRollover is the year-end batch over active students: those who
+// meet the graduation requirements graduate, the rest move up a year if they
+// earned enough credits. Students already in the final year, short of credits
+// or without a study year are flagged for review instead. It runs once per
+// academic_year; dry_run reports the outcome without saving it.
+func RunStudentRollover(c *gin.Context) {
+	var input rolloverInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effective := time.Now()
+	if input.EffectiveDate != nil {
+		effective = *input.EffectiveDate
+	}
+	requirements := loadGraduationRequirements()
+	promotion := loadPromotionRequirements()
+	reason := "Year-end rollover " + input.AcademicYear
+
+	outcomes := []rolloverOutcome{}
+	rollover := models.StudentRollover{AcademicYear: input.AcademicYear}
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var done int64
+		if err := tx.Model(&models.StudentRollover{}).Where("academic_year = ?", input.AcademicYear).Count(&done).Error; err != nil {
+			return err
+		}
+		if done > 0 {
+			return errRolloverDone
+		}
+
+		var students []models.Student
+		if err := tx.Where("status = ?", models.StudentStatusActive).Order("id").Find(&students).Error; err != nil {
+			return err
+		}
+
+		for i := range students {
+			student := &students[i]
+			var summary gpaSummary
+			if err := tx.Raw(cumulativeGPAQuery, student.ID).Scan(&summary).Error; err != nil {
+				return err
+			}
+
+			outcome := rolloverOutcome{StudentID: student.ID, StudentCode: student.StudentCode}
+			switch {
+			case requirements.met(summary):
+				outcome.Action = "graduated"
+				rollover.Graduated++
+				if err := setStudentStatus(tx, student, models.StudentStatusGraduated, &reason, effective); err != nil {
+					return err
+				}
+			case student.Year == nil:
+				outcome.Reason = "Study year is not set"
+			case *student.Year >= maxStudyYears:
+				outcome.Reason = "Exceeded the maximum study duration"
+			case !promotion.met(*student.Year, summary):
+				outcome.Reason = "Not enough earned credits to move up a year"
+			default:
+				year := *student.Year + 1
+				outcome.Action = "promoted"
+				rollover.Promoted++
+				if err := tx.Model(student).Update("year", year).Error; err != nil {
+					return err
+				}
+				student.Year = &year
+			}
+			if outcome.Reason != "" {
+				outcome.Action = "flagged"
+				rollover.Flagged++
+				err := tx.Model(student).Updates(map[string]any{"flagged": true, "flag_reason": outcome.Reason}).Error
+				if err != nil {
+					return err
+				}
+			}
+			outcome.Year = student.Year
+			outcomes = append(outcomes, outcome)
+		}
+
+		if input.DryRun {
+			return errRolloverDryRun
+		}
+		return tx.Create(&rollover).Error
+	})
+	if err != nil && !(input.DryRun && errors.Is(err, errRolloverDryRun)) {
+		if errors.Is(err, errRolloverDone) || errors.Is(err, errStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    outcomes,
+		"count":   len(outcomes),
+		"summary": rollover,
+		"dry_run": input.DryRun,
+	})
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/middleware"
+	"project-backend/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaTokenTTL       = 5 * time.Minute
+	mfaSetupTokenTTL  = 15 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	errTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	errTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	errTwoFactorNotSetUp   = errors.New("start two-factor setup first")
+	errTwoFactorRequired   = errors.New("two-factor authentication is required for this role")
+	errSecondFactor        = errors.New("invalid authentication code")
+)
+
+// amr claims of tokens issued after a TOTP code and after a recovery code
+var (
+	mfaAMR      = []string{"pwd", "otp", auth.AMRMFA}
+	recoveryAMR = []string{"pwd", auth.AMRMFA}
+)
+
+type secondFactorInput struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type verifyLoginInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	secondFactorInput
+}
+
+type enableTwoFactorInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type disableTwoFactorInput struct {
+	Password string `json:"password" binding:"required"`
+	secondFactorInput
+}
+
+// verifySecondFactor checks a TOTP code, or else a recovery code, for user and
+// consumes it. It returns the amr claim to issue.
+func verifySecondFactor(tx *gorm.DB, user *models.User, input secondFactorInput) ([]string, error) {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return nil, errTwoFactorNotEnabled
+	}
+
+	if input.Code != "" {
+		counter, ok := auth.VerifyTOTP(*user.TOTPSecret, input.Code, time.Now(), user.TOTPLastCounter)
+		if !ok {
+			return nil, errSecondFactor
+		}
+		// Only one of several requests with the same code may advance the counter
+		result := tx.Model(user).Where("totp_last_counter < ?", counter).UpdateColumn("totp_last_counter", counter)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, errSecondFactor
+		}
+		user.TOTPLastCounter = counter
+		return mfaAMR, nil
+	}
+
+	if input.RecoveryCode != "" {
+		hash := auth.HashToken(auth.NormalizeRecoveryCode(input.RecoveryCode))
+		result := tx.Model(&models.UserRecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hash).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, errSecondFactor
+		}
+		return recoveryAMR, nil
+	}
+
+	return nil, errSecondFactor
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and returns new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]models.UserRecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.UserRecoveryCode{UserID: userID, CodeHash: auth.HashToken(code)}
+	}
+	return codes, tx.Create(&records).Error
+}
+
+// clearTwoFactor turns 2FA off for user and drops their recovery codes
+func clearTwoFactor(tx *gorm.DB, user *models.User) error {
+	user.TOTPSecret = nil
+	user.TOTPEnabled = false
+	user.TOTPLastCounter = 0
+	err := tx.Model(user).Updates(map[string]any{
+		"totp_secret":       nil,
+		"totp_enabled":      false,
+		"totp_last_counter": 0,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errSecondFactor):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, errTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, errTwoFactorEnabled), errors.Is(err, errTwoFactorNotEnabled), errors.Is(err, errTwoFactorNotSetUp):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// VerifyLoginTwoFactor completes a login for a user with 2FA, exchanging the
+// mfa_token from /auth/login and a TOTP or recovery code for an access token.
+// Wrong codes count towards the account lockout.
+func VerifyLoginTwoFactor(c *gin.Context) {
+	var input verifyLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := auth.ParseToken(input.MFAToken, config.String("JWT_SECRET", ""), time.Now())
+	if err != nil || claims.Scope != auth.ScopeMFA {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login has expired; sign in again"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.Subject).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if user.PasswordChangedAt != nil && claims.IssuedAt < user.PasswordChangedAt.Unix() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login has expired; sign in again"})
+		return
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		respondLocked(c, *user.LockedUntil)
+		return
+	}
+
+	var amr []string
+	err = database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var err error
+		amr, err = verifySecondFactor(tx, &user, input.secondFactorInput)
+		return err
+	})
+	if errors.Is(err, errSecondFactor) {
+		if err := recordFailedLogin(database.DB.WithContext(c.Request.Context()), &user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	if err := recordSuccessfulLogin(database.DB.WithContext(c.Request.Context()), &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	respondAccessToken(c, user, amr)
+}
+
+// SetupTwoFactor starts TOTP enrollment by generating a new secret. The
+// returned otpauth:// URI is what authenticator apps scan as a QR code.
+func SetupTwoFactor(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user.TOTPEnabled {
+		respondTwoFactorError(c, errTwoFactorEnabled)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.WithContext(c.Request.Context()).Model(user).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": auth.TOTPProvisioningURI(config.String("MFA_ISSUER", "Project"), user.Email, secret),
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app.
+// It returns the recovery codes, which are shown only this once, and a new
+// access token carrying the 2FA amr.
+func EnableTwoFactor(c *gin.Context) {
+	var input enableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if user.TOTPEnabled {
+		respondTwoFactorError(c, errTwoFactorEnabled)
+		return
+	}
+	if user.TOTPSecret == nil {
+		respondTwoFactorError(c, errTwoFactorNotSetUp)
+		return
+	}
+	counter, ok := auth.VerifyTOTP(*user.TOTPSecret, input.Code, time.Now(), 0)
+	if !ok {
+		respondTwoFactorError(c, errSecondFactor)
+		return
+	}
+
+	var codes []string
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		user.TOTPEnabled = true
+		user.TOTPLastCounter = counter
+		err := tx.Model(user).Updates(map[string]any{
+			"totp_enabled":      true,
+			"totp_last_counter": counter,
+		}).Error
+		if err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, expires, err := issueToken(*user, mfaAMR)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+		"token":          token,
+		"token_type":     "Bearer",
+		"expires_at":     expires,
+	})
+}
+
+// DisableTwoFactor turns 2FA off after checking the password and a code.
+// Users whose role requires 2FA cannot turn it off.
+func DisableTwoFactor(c *gin.Context) {
+	var input disableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if middleware.MFARequired(*user) {
+		respondTwoFactorError(c, errTwoFactorRequired)
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, input.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if _, err := verifySecondFactor(tx, user, input.secondFactorInput); err != nil {
+			return err
+		}
+		return clearTwoFactor(tx, user)
+	})
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a TOTP code
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var input enableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	var codes []string
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if _, err := verifySecondFactor(tx, user, secondFactorInput{Code: input.Code}); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// ResetUserTwoFactor turns 2FA off for a user who lost their authenticator
+// and recovery codes, so they can enroll again at the next login
+func ResetUserTwoFactor(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return clearTwoFactor(tx, &user)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
@@ -1,13 +1,151 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"project-backend/internal/config"
 	"project-backend/internal/database"
 	"project-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var (
+	errInvalidRole     = errors.New("role must be admin, hr, employee or student")
+	errLinkMismatch    = errors.New("student accounts link to a student record, other roles to an employee record")
+	errLinkedElsewhere = errors.New("record is already linked to another user")
+	errUnknownLink     = errors.New("linked student or employee not found")
+	errEmailTaken      = errors.New("email is already in use")
+)
+
+type userInput struct {
+	Name       string  `json:"name" binding:"required"`
+	Email      string  `json:"email" binding:"required,email"`
+	Password   *string `json:"password"`
+	Role       string  `json:"role"`
+	StudentID  *uint   `json:"student_id"`
+	EmployeeID *uint   `json:"employee_id"`
+}
+
+// apply copies the input onto user; the password is set separately
+func (in userInput) apply(user *models.User) {
+	user.Name = in.Name
+	user.Email = in.Email
+	user.Role = in.Role
+	if user.Role == "" {
+		user.Role = models.UserRoleEmployee
+	}
+	user.StudentID = in.StudentID
+	user.EmployeeID = in.EmployeeID
+}
+
+// validateUser checks the unique email, the role and the one-to-one Student
+// or Employee link
+func validateUser(tx *gorm.DB, user *models.User) error {
+	var taken int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("lower(email) = lower(?) AND id <> ?", user.Email, user.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errEmailTaken
+	}
+
+	switch user.Role {
+	case models.UserRoleAdmin, models.UserRoleHR, models.UserRoleEmployee:
+		if user.StudentID != nil {
+			return errLinkMismatch
+		}
+	case models.UserRoleStudent:
+		if user.EmployeeID != nil {
+			return errLinkMismatch
+		}
+	default:
+		return errInvalidRole
+	}
+
+	if user.StudentID != nil {
+		if err := checkUserLink(tx, &models.Student{}, "student_id", *user.StudentID, user.ID); err != nil {
+			return err
+		}
+	}
+	if user.EmployeeID != nil {
+		return checkUserLink(tx, &models.Employee{}, "employee_id", *user.EmployeeID, user.ID)
+	}
+	return nil
+}
+
+// checkUserLink checks that the linked record exists and no other user,
+// including soft deleted ones, is linked to it
+func checkUserLink(tx *gorm.DB, model any, column string, recordID, userID uint) error {
+	var count int64
+	if err := tx.Model(model).Where("id = ?", recordID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errUnknownLink
+	}
+	if err := tx.Unscoped().Model(&models.User{}).Where(column+" = ? AND id <> ?", recordID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errLinkedElsewhere
+	}
+	return nil
+}
+
+// BootstrapAdmin creates the first administrator from ADMIN_EMAIL and
+// ADMIN_PASSWORD, since only administrators can create accounts. It does
+// nothing once any administrator exists.
+func BootstrapAdmin() error {
+	email := config.String("ADMIN_EMAIL", "")
+	if email == "" {
+		return nil
+	}
+	var admins int64
+	if err := database.DB.Model(&models.User{}).Where("role = ?", models.UserRoleAdmin).Count(&admins).Error; err != nil {
+		return err
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	password := config.String("ADMIN_PASSWORD", "")
+	if password == "" {
+		return fmt.Errorf("ADMIN_PASSWORD is required to create the administrator %s", email)
+	}
+	user := models.User{Name: "Administrator", Email: email, Role: models.UserRoleAdmin}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateUser(tx, &user); err != nil {
+			return err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return setPassword(tx, &user, password)
+	})
+	if err != nil {
+		return fmt.Errorf("creating administrator %s: %w", email, err)
+	}
+	log.Printf("Created administrator %s", email)
+	return nil
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errLinkedElsewhere), errors.Is(err, errEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, errUnknownLink):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, errInvalidRole), errors.Is(err, errLinkMismatch), errors.Is(err, errPasswordPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func GetUsers(c *gin.Context) {
 	var users []models.User
 	result := database.DB.Find(&users)
@@ -19,15 +157,29 @@ func GetUsers(c *gin.Context) {
 }
 
 func CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input userInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result := database.DB.Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	var user models.User
+	input.apply(&user)
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := validateUser(tx, &user); err != nil {
+			return err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if input.Password == nil {
+			return nil
+		}
+		return setPassword(tx, &user, *input.Password)
+	})
+	if err != nil {
+		respondUserError(c, err)
 		return
 	}
 
@@ -37,7 +189,7 @@ func CreateUser(c *gin.Context) {
 func GetUser(c *gin.Context) {
 	id := c.Param("id")
 	var user models.User
-	
+
 	result := database.DB.First(&user, id)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -46,3 +198,40 @@ func GetUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// UpdateUser replaces a user's details, role and Student or Employee link.
+// The password is only changed when one is sent.
+func UpdateUser(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var input userInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.apply(&user)
+
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := validateUser(tx, &user); err != nil {
+			return err
+		}
+		if err := tx.Omit("Student", "Employee").Save(&user).Error; err != nil {
+			return err
+		}
+		if input.Password == nil {
+			return nil
+		}
+		return setPassword(tx, &user, *input.Password)
+	})
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
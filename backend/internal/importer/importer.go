@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Row maps normalized column names to the raw cell values of one data row
+type Row map[string]string
+
+// Reader yields the data rows of an import file. Next returns io.EOF after
+// the last row.
+type Reader interface {
+	Next() (Row, error)
+	Close() error
+}
+
+// DetectFormat derives the import format from a file name
+func DetectFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(filename))
+}
+
+// NormalizeHeader turns a column heading into the snake_case form used as a
+// field name, e.g. " Student Code " becomes "student_code"
+func NormalizeHeader(h string) string {
+	h = strings.TrimPrefix(h, "\uFEFF")
+	return strings.Join(strings.Fields(strings.ToLower(h)), "_")
+}
+
+// Open returns a Reader for the file at path. mapping renames file headings
+// to field names; headings not in mapping are matched by their normalized form.
+func Open(path, format string, mapping map[string]string) (Reader, error) {
+	normalized := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		normalized[NormalizeHeader(from)] = NormalizeHeader(to)
+	}
+
+	switch format {
+	case FormatCSV:
+		return openCSV(path, normalized)
+	case FormatXLSX:
+		return openXLSX(path, normalized)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// CountRows returns the number of data rows in the file, excluding the header
+func CountRows(path, format string) (int, error) {
+	r, err := Open(path, format, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	n := 0
+	for {
+		if _, err := r.Next(); errors.Is(err, io.EOF) {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+func mapHeader(header []string, mapping map[string]string) []string {
+	columns := make([]string, len(header))
+	for i, h := range header {
+		name := NormalizeHeader(h)
+		if mapped, ok := mapping[name]; ok {
+			name = mapped
+		}
+		columns[i] = name
+	}
+	return columns
+}
+
+func buildRow(columns, cells []string) Row {
+	row := make(Row, len(columns))
+	for i, col := range columns {
+		if col == "" || i >= len(cells) {
+			continue
+		}
+		row[col] = strings.TrimSpace(cells[i])
+	}
+	return row
+}
+
+func isBlank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type csvReader struct {
+	file    *os.File
+	r       *csv.Reader
+	columns []string
+}
+
+func openCSV(path string, mapping map[string]string) (*csvReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	return &csvReader{file: file, r: r, columns: mapHeader(header, mapping)}, nil
+}
+
+func (cr *csvReader) Next() (Row, error) {
+	for {
+		cells, err := cr.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		if !isBlank(cells) {
+			return buildRow(cr.columns, cells), nil
+		}
+	}
+}
+
+func (cr *csvReader) Close() error {
+	return cr.file.Close()
+}
+
+type xlsxReader struct {
+	file    *excelize.File
+	rows    *excelize.Rows
+	columns []string
+}
+
+func openXLSX(path string, mapping map[string]string) (*xlsxReader, error) {
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		file.Close()
+		return nil, errors.New("workbook has no sheets")
+	}
+	// Only the first sheet is imported; rows are streamed rather than loaded at once
+	rows, err := file.Rows(sheets[0])
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !rows.Next() {
+		rows.Close()
+		file.Close()
+		return nil, errors.New("reading header: sheet is empty")
+	}
+	header, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		file.Close()
+		return nil, err
+	}
+	return &xlsxReader{file: file, rows: rows, columns: mapHeader(header, mapping)}, nil
+}
+
+func (xr *xlsxReader) Next() (Row, error) {
+	for xr.rows.Next() {
+		cells, err := xr.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if !isBlank(cells) {
+			return buildRow(xr.columns, cells), nil
+		}
+	}
+	if err := xr.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (xr *xlsxReader) Close() error {
+	xr.rows.Close()
+	return xr.file.Close()
+}
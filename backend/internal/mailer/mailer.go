@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"project-backend/internal/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outbound email
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv returns the sender selected by MAIL_DRIVER: smtp (default), file or log
+func FromEnv() (Sender, error) {
+	from := config.String("MAIL_FROM", "no-reply@localhost")
+	switch driver := config.String("MAIL_DRIVER", "smtp"); driver {
+	case "smtp":
+		return &SMTPSender{
+			Host:     config.String("SMTP_HOST", "localhost"),
+			Port:     config.Int("SMTP_PORT", 587),
+			Username: config.String("SMTP_USERNAME", ""),
+			Password: config.String("SMTP_PASSWORD", ""),
+			From:     from,
+		}, nil
+	case "file":
+		return &FileSender{Dir: config.String("MAIL_DIR", "mail"), From: from}, nil
+	case "log":
+		return &LogSender{From: from}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+}
+
+// SMTPSender sends mail through an SMTP server, using STARTTLS when the
+// server offers it and PLAIN auth when a username is set
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	// net/smtp has no context support; run it aside so callers can give up
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.From, []string{msg.To}, format(s.From, msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FileSender writes every message as an .eml file into Dir, for local
+// development and tests
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+	return os.WriteFile(filepath.Join(s.Dir, name), format(s.From, msg), 0o600)
+}
+
+// LogSender writes every message to the application log
+type LogSender struct {
+	From string
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// format renders msg as an RFC 5322 message
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + header(from) + "\r\n")
+	b.WriteString("To: " + header(msg.To) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", header(msg.Subject)) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// header strips line breaks so values cannot inject extra headers
+func header(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
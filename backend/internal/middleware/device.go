@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"project-backend/internal/audit"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DeviceKeyHeader       = "X-Device-Key"
+	DeviceIDHeader        = "X-Device-ID"
+	DeviceTimestampHeader = "X-Device-Timestamp"
+	DeviceSignatureHeader = "X-Device-Signature"
+	DeviceNonceHeader     = "X-Device-Nonce"
+
+	deviceContextKey = "device"
+
+	maxDeviceNonceLength = 64
+	// deviceNoncePruneEvery is how many signed requests pass between deletions
+	// of expired nonces
+	deviceNoncePruneEvery = 500
+)
+
+var signedDeviceRequests atomic.Int64
+
+// DeviceAuth only lets registered, active devices through. A device presents
+// either its key in X-Device-Key, or X-Device-ID with an HMAC signature of the
+// request in X-Device-Signature over X-Device-Timestamp and a single-use
+// X-Device-Nonce. Signatures need DEVICE_SIGNING_SECRET to be configured.
+func DeviceAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device, ok := authenticateDevice(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Device authentication required"})
+			return
+		}
+		if device.Status != models.DeviceStatusActive {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Device is not active"})
+			return
+		}
+
+		now := time.Now()
+		device.LastSeenAt = &now
+		database.DB.Model(&device).UpdateColumn("last_seen_at", now)
+
+		c.Set(deviceContextKey, &device)
+		audit.SetActor(c.Request.Context(), audit.Actor{Type: "device", ID: device.ID})
+		c.Next()
+	}
+}
+
+// CurrentDevice returns the device authenticated for this request, if any
+func CurrentDevice(c *gin.Context) *models.Device {
+	if v, ok := c.Get(deviceContextKey); ok {
+		return v.(*models.Device)
+	}
+	return nil
+}
+
+func authenticateDevice(c *gin.Context) (models.Device, bool) {
+	var device models.Device
+
+	if key := c.GetHeader(DeviceKeyHeader); key != "" {
+		prefix, ok := auth.DeviceKeyPrefix(key)
+		if !ok {
+			return device, false
+		}
+		if err := database.DB.Where("key_prefix = ?", prefix).First(&device).Error; err != nil {
+			return device, false
+		}
+		return device, auth.VerifyDeviceKey(key, device.KeyHash)
+	}
+
+	id := c.GetHeader(DeviceIDHeader)
+	signature := c.GetHeader(DeviceSignatureHeader)
+	timestamp := c.GetHeader(DeviceTimestampHeader)
+	nonce := c.GetHeader(DeviceNonceHeader)
+	secret := config.String("DEVICE_SIGNING_SECRET", "")
+	if id == "" || signature == "" || timestamp == "" || nonce == "" || len(nonce) > maxDeviceNonceLength || secret == "" {
+		return device, false
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return device, false
+	}
+	maxSkew := time.Duration(config.Int("DEVICE_SIGNATURE_MAX_SKEW_SECONDS", 300)) * time.Second
+	if skew := time.Since(time.Unix(unix, 0)); skew > maxSkew || skew < -maxSkew {
+		return device, false
+	}
+
+	if err := database.DB.First(&device, id).Error; err != nil {
+		return device, false
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return device, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	key := auth.DeviceSigningKey(secret, device.KeyPrefix)
+	if !auth.VerifyDeviceSignature(signature, key, c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body) {
+		return device, false
+	}
+	return device, useDeviceNonce(device.ID, nonce, time.Unix(unix, 0).Add(maxSkew))
+}
+
+// useDeviceNonce records a nonce and reports whether it was unused. Once the
+// nonce expires its timestamp is outside the allowed skew, so it can go.
+func useDeviceNonce(deviceID uint, nonce string, expires time.Time) bool {
+	if signedDeviceRequests.Add(1)%deviceNoncePruneEvery == 0 {
+		database.DB.Where("expires_at < ?", time.Now()).Delete(&models.DeviceNonce{})
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.DeviceNonce{DeviceID: deviceID, Nonce: nonce, ExpiresAt: expires})
+	return result.Error == nil && result.RowsAffected == 1
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	skipIdempotencyKey = "idempotency.skip"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyPruneEvery is how many keyed requests pass between deletions
+	// of expired keys
+	idempotencyPruneEvery = 500
+)
+
+var idempotentRequests atomic.Int64
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry. The first response for a key is stored for IDEMPOTENCY_TTL_HOURS and
+// replayed for retries with the same body; reusing the key with a different
+// request is refused with 422, and a retry while the first attempt is still
+// running gets 409. Server errors are not stored, so they can be retried.
+// It must run after UserAuth or DeviceAuth: keys are scoped to the verified
+// user or device, and requests without one are passed through untouched.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		principal := idempotencyPrincipal(c)
+		if c.Request.Method != http.MethodPost || key == "" || principal == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := models.IdempotencyKey{
+			Key:         idempotencyScope(principal, key),
+			Fingerprint: requestFingerprint(c, body),
+			Status:      models.IdempotencyProcessing,
+			ExpiresAt:   time.Now().Add(time.Duration(config.Int("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour),
+		}
+		if idempotentRequests.Add(1)%idempotencyPruneEvery == 0 {
+			database.DB.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+		}
+
+		claimed, err := claimIdempotencyKey(&record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !claimed {
+			replayIdempotent(c, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			// Release the key if the handler failed or panicked
+			if !completed {
+				database.DB.Delete(&models.IdempotencyKey{}, "key = ?", record.Key)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || c.GetBool(skipIdempotencyKey) {
+			return
+		}
+		err = database.DB.Model(&models.IdempotencyKey{}).Where("key = ?", record.Key).Updates(map[string]any{
+			"status":          models.IdempotencyCompleted,
+			"response_status": status,
+			"response_body":   recorder.body.Bytes(),
+			"content_type":    c.Writer.Header().Get("Content-Type"),
+		}).Error
+		if err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// claimIdempotencyKey inserts record unless a live entry holds its key. When
+// the key is taken, record is replaced by the stored entry.
+func claimIdempotencyKey(record *models.IdempotencyKey) (bool, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return true, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := database.DB.Where("key = ?", record.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released between the insert and the lookup; try again
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if existing.ExpiresAt.After(time.Now()) {
+			fingerprint := record.Fingerprint
+			*record = existing
+			if existing.Fingerprint != fingerprint {
+				record.Status = ""
+			}
+			return false, nil
+		}
+		if err := database.DB.Delete(&models.IdempotencyKey{}, "key = ? AND expires_at <= ?", record.Key, time.Now()).Error; err != nil {
+			return false, err
+		}
+	}
+	return false, errors.New("could not claim idempotency key")
+}
+
+// replayIdempotent answers a request whose key is already in use
+func replayIdempotent(c *gin.Context, record models.IdempotencyKey) {
+	switch record.Status {
+	case models.IdempotencyCompleted:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(record.ResponseStatus, record.ContentType, record.ResponseBody)
+		c.Abort()
+	case models.IdempotencyProcessing:
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+	default:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	}
+}
+
+// SkipIdempotency keeps the response of this request out of the idempotency
+// store, for responses carrying credentials that must not be kept. A retry
+// then runs the request again.
+func SkipIdempotency(c *gin.Context) {
+	c.Set(skipIdempotencyKey, true)
+}
+
+// idempotencyPrincipal identifies the verified user or device of the request
+func idempotencyPrincipal(c *gin.Context) string {
+	if user := CurrentUser(c); user != nil {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	if device := CurrentDevice(c); device != nil {
+		return "device:" + strconv.FormatUint(uint64(device.ID), 10)
+	}
+	return ""
+}
+
+// idempotencyScope hashes the key together with the principal, so one client
+// can never see another client's stored responses
+func idempotencyScope(principal, key string) string {
+	sum := sha256.Sum256([]byte(principal + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies the request a key was first used for
+func requestFingerprint(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + "\n" + c.Request.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"project-backend/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestContext assigns every request an ID, taken from X-Request-ID when
+// the client or a proxy sent one, and attaches it with the client IP to the
+// request context for the audit log. Authentication middleware sets the actor.
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		info := &audit.Info{RequestID: requestID, IP: c.ClientIP()}
+		c.Request = c.Request.WithContext(audit.WithInfo(c.Request.Context(), info))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"project-backend/internal/audit"
+	"project-backend/internal/auth"
+	"project-backend/internal/config"
+	"project-backend/internal/database"
+	"project-backend/internal/models"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userContextKey   = "user"
+	claimsContextKey = "claims"
+)
+
+// UserAuth only lets requests through that carry a valid access token in
+// "Authorization: Bearer <token>" for a user that still exists and has not
+// changed their password since the token was issued. Restricted tokens are
+// refused unless their scope is listed in scopes, and so are full tokens
+// issued without a second factor to users whose role requires 2FA.
+func UserAuth(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		claims, err := auth.ParseToken(token, config.String("JWT_SECRET", ""), time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if claims.Scope != "" && !slices.Contains(scopes, claims.Scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token is not valid for this endpoint", "scope": claims.Scope})
+			return
+		}
+
+		var user models.User
+		if err := database.DB.First(&user, claims.Subject).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if user.PasswordChangedAt != nil && claims.IssuedAt < user.PasswordChangedAt.Unix() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token was revoked by a password change"})
+			return
+		}
+
+		if claims.Scope == "" && MFARequired(user) && !slices.Contains(claims.AMR, auth.AMRMFA) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role; sign in again", "mfa_required": true})
+			return
+		}
+
+		c.Set(userContextKey, &user)
+		c.Set(claimsContextKey, claims)
+		audit.SetActor(c.Request.Context(), audit.Actor{Type: "user", ID: user.ID})
+		c.Next()
+	}
+}
+
+// MFARequired reports whether policy requires 2FA for the user's role. With
+// MFA_REQUIRED_FOR_PRIVILEGED, the roles in MFA_PRIVILEGED_ROLES must use it.
+func MFARequired(user models.User) bool {
+	if !config.Bool("MFA_REQUIRED_FOR_PRIVILEGED", false) {
+		return false
+	}
+	roles := strings.Split(config.String("MFA_PRIVILEGED_ROLES", "admin,hr"), ",")
+	for i := range roles {
+		roles[i] = strings.TrimSpace(roles[i])
+	}
+	return slices.Contains(roles, user.Role)
+}
+
+// CurrentUser returns the user authenticated for this request, if any
+func CurrentUser(c *gin.Context) *models.User {
+	if v, ok := c.Get(userContextKey); ok {
+		return v.(*models.User)
+	}
+	return nil
+}
+
+// CurrentClaims returns the token claims of the authenticated user
+func CurrentClaims(c *gin.Context) auth.Claims {
+	claims, _ := c.Get(claimsContextKey)
+	v, _ := claims.(auth.Claims)
+	return v
+}
+
+// RequireRole only lets users through whose role is one of roles. It must run
+// after UserAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		if !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your role is not allowed to use this endpoint"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AttendanceStatus string
+
+const (
+	AttendanceStatusPresent AttendanceStatus = "present"
+	AttendanceStatusLate    AttendanceStatus = "late"
+	AttendanceStatusAbsent  AttendanceStatus = "absent"
+)
+
+type Shift struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"unique;not null;size:100"`
+	StartTime    string         `json:"start_time" gorm:"column:start_time;not null;size:5"`
+	EndTime      string         `json:"end_time" gorm:"column:end_time;not null;size:5"`
+	GraceMinutes int            `json:"grace_minutes" gorm:"column:grace_minutes;default:0;not null"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+type AttendanceRecord struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	EmployeeID        uint             `json:"employee_id" gorm:"column:employee_id;not null;uniqueIndex:idx_attendance_employee_date"`
+	Date              time.Time        `json:"date" gorm:"type:date;not null;uniqueIndex:idx_attendance_employee_date"`
+	CheckIn           *time.Time       `json:"check_in" gorm:"column:check_in"`
+	CheckOut          *time.Time       `json:"check_out" gorm:"column:check_out"`
+	Status            AttendanceStatus `json:"status" gorm:"size:20;default:'present';not null"`
+	LateMinutes       int              `json:"late_minutes" gorm:"column:late_minutes;default:0;not null"`
+	OvertimeMinutes   int              `json:"overtime_minutes" gorm:"column:overtime_minutes;default:0;not null"`
+	DeviceID          *uint            `json:"device_id" gorm:"column:device_id;index"`
+	CheckOutDeviceID  *uint            `json:"check_out_device_id" gorm:"column:check_out_device_id"`
+	Latitude          *float64         `json:"latitude" gorm:"column:latitude"`
+	Longitude         *float64         `json:"longitude" gorm:"column:longitude"`
+	AccuracyMeters    *float64         `json:"accuracy_meters" gorm:"column:accuracy_meters"`
+	CheckOutLatitude  *float64         `json:"check_out_latitude" gorm:"column:check_out_latitude"`
+	CheckOutLongitude *float64         `json:"check_out_longitude" gorm:"column:check_out_longitude"`
+	GeofenceID        *uint            `json:"geofence_id" gorm:"column:geofence_id"`
+	Flagged           bool             `json:"flagged" gorm:"default:false;not null;index"`
+	FlagReason        *string          `json:"flag_reason" gorm:"column:flag_reason;size:255"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+
+	// Relationships
+	Employee *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Device   *Device   `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
+}
+
+// TableName specifies the table name for Shift model
+func (Shift) TableName() string {
+	return "shifts"
+}
+
+// TableName specifies the table name for AttendanceRecord model
+func (AttendanceRecord) TableName() string {
+	return "attendance_records"
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditData is a row snapshot, column name to value, stored as JSON
+type AuditData map[string]any
+
+// Value implements driver.Valuer
+func (d AuditData) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (d *AuditData) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("cannot scan %T into AuditData", value)
+	}
+}
+
+// AuditEvent records one create, update or delete of a row. Creates carry
+// the new row in After, deletes the old row in Before, and updates only the
+// changed columns in both. The table is append-only.
+type AuditEvent struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	ActorType  *string     `json:"actor_type" gorm:"column:actor_type;size:20;index:idx_audit_actor"`
+	ActorID    *uint       `json:"actor_id" gorm:"column:actor_id;index:idx_audit_actor"`
+	Action     AuditAction `json:"action" gorm:"size:10;not null"`
+	EntityType string      `json:"entity_type" gorm:"column:entity_type;size:64;not null;index:idx_audit_entity"`
+	EntityID   string      `json:"entity_id" gorm:"column:entity_id;size:64;not null;index:idx_audit_entity"`
+	Before     AuditData   `json:"before" gorm:"type:jsonb"`
+	After      AuditData   `json:"after" gorm:"type:jsonb"`
+	RequestID  *string     `json:"request_id" gorm:"column:request_id;size:64;index"`
+	IP         *string     `json:"ip" gorm:"column:ip;size:64"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for AuditEvent model
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ClassSession is one meeting of a course in a semester
+type ClassSession struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CourseID   uint           `json:"course_id" gorm:"column:course_id;not null;index:idx_class_session_course_semester"`
+	SemesterID uint           `json:"semester_id" gorm:"column:semester_id;not null;index:idx_class_session_course_semester"`
+	Date       time.Time      `json:"date" gorm:"type:date;not null"`
+	StartTime  *string        `json:"start_time" gorm:"column:start_time;size:5"` // "HH:MM"
+	Room       *string        `json:"room" gorm:"size:50"`
+	LecturerID *uint          `json:"lecturer_id" gorm:"column:lecturer_id;index"`
+	Topic      *string        `json:"topic" gorm:"size:255"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Course   *Course   `json:"course,omitempty" gorm:"foreignKey:CourseID"`
+	Semester *Semester `json:"semester,omitempty" gorm:"foreignKey:SemesterID"`
+	Lecturer *Employee `json:"lecturer,omitempty" gorm:"foreignKey:LecturerID"`
+}
+
+type StudentAttendanceStatus string
+
+const (
+	StudentAttendancePresent StudentAttendanceStatus = "present"
+	StudentAttendanceLate    StudentAttendanceStatus = "late"
+	StudentAttendanceAbsent  StudentAttendanceStatus = "absent"
+	StudentAttendanceExcused StudentAttendanceStatus = "excused"
+)
+
+// StudentAttendance is a student's attendance at one class session
+type StudentAttendance struct {
+	ID             uint                    `json:"id" gorm:"primaryKey"`
+	ClassSessionID uint                    `json:"class_session_id" gorm:"column:class_session_id;not null;uniqueIndex:idx_student_attendance_session"`
+	StudentID      uint                    `json:"student_id" gorm:"column:student_id;not null;uniqueIndex:idx_student_attendance_session;index"`
+	Status         StudentAttendanceStatus `json:"status" gorm:"size:20;not null;check:status IN ('present', 'late', 'absent', 'excused')"`
+	Note           *string                 `json:"note" gorm:"size:500"`
+	MarkedByID     *uint                   `json:"marked_by_id" gorm:"column:marked_by_id"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+
+	// Relationships
+	Student *Student `json:"student,omitempty" gorm:"foreignKey:StudentID"`
+}
+
+// TableName specifies the table name for ClassSession model
+func (ClassSession) TableName() string {
+	return "class_sessions"
+}
+
+// TableName specifies the table name for StudentAttendance model
+func (StudentAttendance) TableName() string {
+	return "student_attendances"
+}
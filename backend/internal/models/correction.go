@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CorrectionStatus string
+
+const (
+	CorrectionStatusPending  CorrectionStatus = "pending"
+	CorrectionStatusApproved CorrectionStatus = "approved"
+	CorrectionStatusRejected CorrectionStatus = "rejected"
+)
+
+type AttendanceCorrection struct {
+	ID                 uint             `json:"id" gorm:"primaryKey"`
+	EmployeeID         uint             `json:"employee_id" gorm:"column:employee_id;not null;index"`
+	AttendanceRecordID *uint            `json:"attendance_record_id" gorm:"column:attendance_record_id;index"`
+	Date               time.Time        `json:"date" gorm:"type:date;not null"`
+	ProposedCheckIn    *time.Time       `json:"proposed_check_in" gorm:"column:proposed_check_in"`
+	ProposedCheckOut   *time.Time       `json:"proposed_check_out" gorm:"column:proposed_check_out"`
+	Reason             string           `json:"reason" gorm:"not null;size:500"`
+	Status             CorrectionStatus `json:"status" gorm:"size:20;default:'pending';not null"`
+	ApproverID         *uint            `json:"approver_id" gorm:"column:approver_id"`
+	ReviewedAt         *time.Time       `json:"reviewed_at" gorm:"column:reviewed_at"`
+	ReviewNote         *string          `json:"review_note" gorm:"column:review_note;size:500"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Employee    *Employee                        `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Approver    *Employee                        `json:"approver,omitempty" gorm:"foreignKey:ApproverID"`
+	Attachments []AttendanceCorrectionAttachment `json:"attachments,omitempty" gorm:"foreignKey:CorrectionID"`
+}
+
+type AttendanceCorrectionAttachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CorrectionID uint      `json:"correction_id" gorm:"column:correction_id;not null;index"`
+	FileName     string    `json:"file_name" gorm:"column:file_name;not null;size:255"`
+	URL          string    `json:"url" gorm:"not null;size:1000"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AttendanceRecordHistory keeps the values a record held before a correction was applied
+type AttendanceRecordHistory struct {
+	ID                 uint             `json:"id" gorm:"primaryKey"`
+	AttendanceRecordID uint             `json:"attendance_record_id" gorm:"column:attendance_record_id;not null;index"`
+	CorrectionID       *uint            `json:"correction_id" gorm:"column:correction_id"`
+	CheckIn            *time.Time       `json:"check_in" gorm:"column:check_in"`
+	CheckOut           *time.Time       `json:"check_out" gorm:"column:check_out"`
+	Status             AttendanceStatus `json:"status" gorm:"size:20;not null"`
+	LateMinutes        int              `json:"late_minutes" gorm:"column:late_minutes;not null"`
+	OvertimeMinutes    int              `json:"overtime_minutes" gorm:"column:overtime_minutes;not null"`
+	CreatedAt          time.Time        `json:"created_at"`
+}
+
+// TableName specifies the table name for AttendanceCorrection model
+func (AttendanceCorrection) TableName() string {
+	return "attendance_corrections"
+}
+
+// TableName specifies the table name for AttendanceCorrectionAttachment model
+func (AttendanceCorrectionAttachment) TableName() string {
+	return "attendance_correction_attachments"
+}
+
+// TableName specifies the table name for AttendanceRecordHistory model
+func (AttendanceRecordHistory) TableName() string {
+	return "attendance_record_history"
+}
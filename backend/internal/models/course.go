@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Course struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Code        string         `json:"code" gorm:"unique;not null;size:20"`
+	Title       string         `json:"title" gorm:"not null;size:255"`
+	Credits     int            `json:"credits" gorm:"not null;check:credits > 0"`
+	Major       *string        `json:"major" gorm:"size:100"`
+	Description *string        `json:"description" gorm:"size:1000"`
+	Capacity    int            `json:"capacity" gorm:"not null;check:capacity > 0"`
+	MaxAbsences *int           `json:"max_absences" gorm:"column:max_absences"` // overrides ABSENCE_MAX_RATE
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Prerequisites []Course `json:"prerequisites,omitempty" gorm:"many2many:course_prerequisites;joinForeignKey:CourseID;joinReferences:PrerequisiteID"`
+}
+
+type Semester struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Code      string         `json:"code" gorm:"unique;not null;size:20"`
+	Name      string         `json:"name" gorm:"not null;size:100"`
+	StartDate time.Time      `json:"start_date" gorm:"column:start_date;type:date;not null"`
+	EndDate   time.Time      `json:"end_date" gorm:"column:end_date;type:date;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentStatusEnrolled   EnrollmentStatus = "enrolled"
+	EnrollmentStatusWaitlisted EnrollmentStatus = "waitlisted"
+	EnrollmentStatusDropped    EnrollmentStatus = "dropped"
+	EnrollmentStatusCompleted  EnrollmentStatus = "completed"
+)
+
+// Enrollment places a student in a course for a semester. When the course is
+// full the student is waitlisted and promoted in WaitlistedAt order as seats
+// free up. Recording a grade completes the enrollment. A student holds at
+// most one live (not dropped) enrollment per course and semester.
+type Enrollment struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	StudentID    uint             `json:"student_id" gorm:"column:student_id;not null;index;uniqueIndex:idx_enrollment_live,where:status <> 'dropped'"`
+	CourseID     uint             `json:"course_id" gorm:"column:course_id;not null;index:idx_enrollment_course_semester;uniqueIndex:idx_enrollment_live"`
+	SemesterID   uint             `json:"semester_id" gorm:"column:semester_id;not null;index:idx_enrollment_course_semester;uniqueIndex:idx_enrollment_live"`
+	Status       EnrollmentStatus `json:"status" gorm:"size:20;not null"`
+	WaitlistedAt *time.Time       `json:"waitlisted_at" gorm:"column:waitlisted_at"`
+	EnrolledAt   *time.Time       `json:"enrolled_at" gorm:"column:enrolled_at"`
+	DroppedAt    *time.Time       `json:"dropped_at" gorm:"column:dropped_at"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+
+	// WaitlistPosition is filled in for waitlisted enrollments when returned
+	WaitlistPosition *int `json:"waitlist_position,omitempty" gorm:"-"`
+
+	// Relationships
+	Student  *Student  `json:"student,omitempty" gorm:"foreignKey:StudentID"`
+	Course   *Course   `json:"course,omitempty" gorm:"foreignKey:CourseID"`
+	Semester *Semester `json:"semester,omitempty" gorm:"foreignKey:SemesterID"`
+	Grade    *Grade    `json:"grade,omitempty" gorm:"foreignKey:EnrollmentID"`
+}
+
+// TableName specifies the table name for Course model
+func (Course) TableName() string {
+	return "courses"
+}
+
+// TableName specifies the table name for Semester model
+func (Semester) TableName() string {
+	return "semesters"
+}
+
+// TableName specifies the table name for Enrollment model
+func (Enrollment) TableName() string {
+	return "enrollments"
+}
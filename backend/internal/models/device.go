@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeviceStatus string
+
+const (
+	DeviceStatusActive   DeviceStatus = "active"
+	DeviceStatusInactive DeviceStatus = "inactive"
+	DeviceStatusRevoked  DeviceStatus = "revoked"
+)
+
+type Device struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"not null;size:100"`
+	Location     *string        `json:"location" gorm:"size:255"`
+	DepartmentID *uint          `json:"department_id" gorm:"column:department_id"`
+	Status       DeviceStatus   `json:"status" gorm:"size:20;default:'active';not null"`
+	KeyPrefix    string         `json:"key_prefix" gorm:"column:key_prefix;uniqueIndex;not null;size:16"`
+	KeyHash      string         `json:"-" gorm:"column:key_hash;not null;size:64"`
+	KeyRotatedAt *time.Time     `json:"key_rotated_at" gorm:"column:key_rotated_at"`
+	LastSeenAt   *time.Time     `json:"last_seen_at" gorm:"column:last_seen_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Department *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+}
+
+// TableName specifies the table name for Device model
+func (Device) TableName() string {
+	return "devices"
+}
+
+// DeviceNonce is a nonce already used in a signed device request, kept until
+// the request's timestamp falls out of the allowed clock skew
+type DeviceNonce struct {
+	DeviceID  uint      `json:"device_id" gorm:"primaryKey;autoIncrement:false"`
+	Nonce     string    `json:"nonce" gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at;not null;index"`
+}
+
+// TableName specifies the table name for DeviceNonce model
+func (DeviceNonce) TableName() string {
+	return "device_nonces"
+}
@@ -9,43 +9,52 @@ import (
 type EmployeeStatus string
 
 const (
-	EmployeeStatusActive    EmployeeStatus = "active"
-	EmployeeStatusInactive  EmployeeStatus = "inactive"
-	EmployeeStatusSuspended EmployeeStatus = "suspended"
+	EmployeeStatusActive     EmployeeStatus = "active"
+	EmployeeStatusInactive   EmployeeStatus = "inactive"
+	EmployeeStatusSuspended  EmployeeStatus = "suspended"
+	EmployeeStatusTerminated EmployeeStatus = "terminated"
 )
 
 type Employee struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	FirstName      string         `json:"first_name" gorm:"column:first_name;not null"`
-	LastName       string         `json:"last_name" gorm:"column:last_name;not null"`
-	Email          string         `json:"email" gorm:"unique;not null"`
-	Phone          *string        `json:"phone" gorm:"column:phone"`
-	DepartmentID   *uint          `json:"department_id" gorm:"column:department_id"`
-	Position       *string        `json:"position" gorm:"column:position"`
-	Status         EmployeeStatus `json:"status" gorm:"type:employee_status;default:active;not null"`
-	FaceDescriptor any            `json:"face_descriptor,omitempty" gorm:"column:face_descriptor;type:vector"`
-	JoinDate       time.Time      `json:"join_date" gorm:"column:join_date;default:now();not null"`
-	EmployeeID     *string        `json:"employee_id" gorm:"column:employee_id;size:20"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	FirstName         string         `json:"first_name" gorm:"column:first_name;not null"`
+	LastName          string         `json:"last_name" gorm:"column:last_name;not null"`
+	Email             string         `json:"email" gorm:"unique;not null"`
+	Phone             *string        `json:"phone" gorm:"column:phone"`
+	DepartmentID      *uint          `json:"department_id" gorm:"column:department_id"`
+	Position          *string        `json:"position" gorm:"column:position"`
+	ShiftID           *uint          `json:"shift_id" gorm:"column:shift_id"`
+	Status            EmployeeStatus `json:"status" gorm:"type:employee_status;default:active;not null"`
+	FaceDescriptor    any            `json:"face_descriptor,omitempty" gorm:"column:face_descriptor;type:vector"`
+	JoinDate          time.Time      `json:"join_date" gorm:"column:join_date;default:now();not null"`
+	EmployeeID        *string        `json:"employee_id" gorm:"column:employee_id;size:32;uniqueIndex"`
+	TerminationDate   *time.Time     `json:"termination_date" gorm:"column:termination_date;type:date"`
+	TerminationReason *string        `json:"termination_reason" gorm:"column:termination_reason;size:500"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
 	Department *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+	Shift      *Shift      `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
 }
 
 type Department struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"unique;not null"`
+	Code        *string        `json:"code" gorm:"size:10"`
 	Description *string        `json:"description"`
 	ManagerID   *uint          `json:"manager_id" gorm:"column:manager_id"`
+	ParentID    *uint          `json:"parent_id" gorm:"column:parent_id;index"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
-	Manager   *Employee  `json:"manager,omitempty" gorm:"foreignKey:ManagerID"`
-	Employees []Employee `json:"employees,omitempty" gorm:"foreignKey:DepartmentID"`
+	Manager   *Employee    `json:"manager,omitempty" gorm:"foreignKey:ManagerID"`
+	Parent    *Department  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Children  []Department `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Employees []Employee   `json:"employees,omitempty" gorm:"foreignKey:DepartmentID"`
 }
 
 // TableName specifies the table name for Employee model
@@ -57,3 +66,38 @@ func (Employee) TableName() string {
 func (Department) TableName() string {
 	return "departments"
 }
+
+// EmployeeStatusHistory records every lifecycle transition of an employee
+type EmployeeStatusHistory struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	EmployeeID    uint           `json:"employee_id" gorm:"column:employee_id;not null;index"`
+	FromStatus    EmployeeStatus `json:"from_status" gorm:"column:from_status;size:20"`
+	ToStatus      EmployeeStatus `json:"to_status" gorm:"column:to_status;size:20;not null"`
+	Reason        *string        `json:"reason" gorm:"size:500"`
+	EffectiveDate time.Time      `json:"effective_date" gorm:"column:effective_date;type:date;not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name for EmployeeStatusHistory model
+func (EmployeeStatusHistory) TableName() string {
+	return "employee_status_history"
+}
+
+// DepartmentManagerHistory records who managed a department and when
+type DepartmentManagerHistory struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	DepartmentID  uint       `json:"department_id" gorm:"column:department_id;not null;index"`
+	ManagerID     *uint      `json:"manager_id" gorm:"column:manager_id"`
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"column:effective_from;type:date;not null"`
+	EffectiveTo   *time.Time `json:"effective_to" gorm:"column:effective_to;type:date"`
+	Reason        *string    `json:"reason" gorm:"size:500"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Relationships
+	Manager *Employee `json:"manager,omitempty" gorm:"foreignKey:ManagerID"`
+}
+
+// TableName specifies the table name for DepartmentManagerHistory model
+func (DepartmentManagerHistory) TableName() string {
+	return "department_manager_history"
+}
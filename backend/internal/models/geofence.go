@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"project-backend/internal/geo"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GeofenceType string
+
+const (
+	GeofenceTypeCircle  GeofenceType = "circle"
+	GeofenceTypePolygon GeofenceType = "polygon"
+)
+
+// GeoPolygon is a list of vertices stored as a JSON array
+type GeoPolygon []geo.Point
+
+// Value implements driver.Valuer
+func (p GeoPolygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (p *GeoPolygon) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("cannot scan %T into GeoPolygon", value)
+	}
+}
+
+type Geofence struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	DepartmentID uint           `json:"department_id" gorm:"column:department_id;not null;index"`
+	Name         string         `json:"name" gorm:"not null;size:100"`
+	Type         GeofenceType   `json:"type" gorm:"size:20;not null;check:type IN ('circle', 'polygon')"`
+	CenterLat    *float64       `json:"center_lat" gorm:"column:center_lat"`
+	CenterLng    *float64       `json:"center_lng" gorm:"column:center_lng"`
+	RadiusMeters *float64       `json:"radius_meters" gorm:"column:radius_meters"`
+	Polygon      GeoPolygon     `json:"polygon,omitempty" gorm:"type:jsonb"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Department *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+}
+
+// Contains reports whether p lies within the geofence
+func (g Geofence) Contains(p geo.Point) bool {
+	switch g.Type {
+	case GeofenceTypeCircle:
+		if g.CenterLat == nil || g.CenterLng == nil || g.RadiusMeters == nil {
+			return false
+		}
+		return geo.InCircle(p, geo.Point{Lat: *g.CenterLat, Lng: *g.CenterLng}, *g.RadiusMeters)
+	case GeofenceTypePolygon:
+		return geo.InPolygon(p, g.Polygon)
+	}
+	return false
+}
+
+// TableName specifies the table name for Geofence model
+func (Geofence) TableName() string {
+	return "geofences"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Grade is the final result of an enrollment. Score is the optional raw
+// 10-point score; Letter and Points come from the grading scale in force
+// when the grade was recorded.
+type Grade struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	EnrollmentID uint      `json:"enrollment_id" gorm:"column:enrollment_id;not null;uniqueIndex"`
+	Score        *float64  `json:"score" gorm:"type:decimal(4,2);check:score >= 0 AND score <= 10"`
+	Letter       string    `json:"letter" gorm:"size:4;not null"`
+	Points       float64   `json:"points" gorm:"type:decimal(3,2);not null;check:points >= 0 AND points <= 4"`
+	Passed       bool      `json:"passed" gorm:"not null"`
+	GradedAt     time.Time `json:"graded_at" gorm:"column:graded_at;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Grade model
+func (Grade) TableName() string {
+	return "grades"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyKey remembers the response to a POST sent with an
+// Idempotency-Key header so that retries get the same response
+type IdempotencyKey struct {
+	Key            string    `json:"key" gorm:"primaryKey;size:64"` // SHA-256 of the client scope and the header value
+	Fingerprint    string    `json:"fingerprint" gorm:"size:64;not null"`
+	Status         string    `json:"status" gorm:"size:20;not null"`
+	ResponseStatus int       `json:"response_status" gorm:"column:response_status"`
+	ResponseBody   []byte    `json:"-" gorm:"column:response_body"`
+	ContentType    string    `json:"content_type" gorm:"column:content_type;size:100"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at" gorm:"column:expires_at;not null;index"`
+}
+
+// TableName specifies the table name for IdempotencyKey model
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusPending   ImportStatus = "pending"
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusCompleted ImportStatus = "completed"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+type ImportMode string
+
+const (
+	ImportModeAllOrNothing ImportMode = "all_or_nothing"
+	ImportModePartial      ImportMode = "partial"
+)
+
+// ImportRowError describes why a row of an import file was rejected
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportRowErrors is stored as a JSON array
+type ImportRowErrors []ImportRowError
+
+// Value implements driver.Valuer
+func (e ImportRowErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (e *ImportRowErrors) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return fmt.Errorf("cannot scan %T into ImportRowErrors", value)
+	}
+}
+
+type ImportJob struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	EntityType    string          `json:"entity_type" gorm:"column:entity_type;size:20;not null"`
+	FileName      string          `json:"file_name" gorm:"column:file_name;size:255;not null"`
+	Status        ImportStatus    `json:"status" gorm:"size:20;default:'pending';not null"`
+	Mode          ImportMode      `json:"mode" gorm:"size:20;not null"`
+	DryRun        bool            `json:"dry_run" gorm:"column:dry_run;not null"`
+	UpsertKey     string          `json:"upsert_key" gorm:"column:upsert_key;size:50;not null"`
+	TotalRows     int             `json:"total_rows" gorm:"column:total_rows;not null;default:0"`
+	ProcessedRows int             `json:"processed_rows" gorm:"column:processed_rows;not null;default:0"`
+	CreatedCount  int             `json:"created_count" gorm:"column:created_count;not null;default:0"`
+	UpdatedCount  int             `json:"updated_count" gorm:"column:updated_count;not null;default:0"`
+	FailedCount   int             `json:"failed_count" gorm:"column:failed_count;not null;default:0"`
+	Committed     bool            `json:"committed" gorm:"not null;default:false"`
+	Errors        ImportRowErrors `json:"errors" gorm:"type:jsonb"`
+	Message       *string         `json:"message" gorm:"size:500"`
+	StartedAt     *time.Time      `json:"started_at" gorm:"column:started_at"`
+	FinishedAt    *time.Time      `json:"finished_at" gorm:"column:finished_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// TableName specifies the table name for ImportJob model
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LeaveStatus string
+
+const (
+	LeaveStatusPending  LeaveStatus = "pending"
+	LeaveStatusApproved LeaveStatus = "approved"
+	LeaveStatusRejected LeaveStatus = "rejected"
+)
+
+type LeaveRequest struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	EmployeeID uint           `json:"employee_id" gorm:"column:employee_id;not null;index"`
+	StartDate  time.Time      `json:"start_date" gorm:"column:start_date;type:date;not null"`
+	EndDate    time.Time      `json:"end_date" gorm:"column:end_date;type:date;not null"`
+	Type       string         `json:"type" gorm:"size:20;default:'annual';not null"`
+	Reason     *string        `json:"reason" gorm:"size:500"`
+	Status     LeaveStatus    `json:"status" gorm:"size:20;default:'pending';not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Employee *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+}
+
+// TableName specifies the table name for LeaveRequest model
+func (LeaveRequest) TableName() string {
+	return "leave_requests"
+}
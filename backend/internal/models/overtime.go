@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OvertimeStatus string
+
+const (
+	OvertimeStatusPending  OvertimeStatus = "pending"
+	OvertimeStatusApproved OvertimeStatus = "approved"
+	OvertimeStatusRejected OvertimeStatus = "rejected"
+)
+
+type DayType string
+
+const (
+	DayTypeWeekday DayType = "weekday"
+	DayTypeWeekend DayType = "weekend"
+	DayTypeHoliday DayType = "holiday"
+)
+
+type Holiday struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      time.Time `json:"date" gorm:"type:date;unique;not null"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OvertimeRequest struct {
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	EmployeeID         uint           `json:"employee_id" gorm:"column:employee_id;not null;index"`
+	AttendanceRecordID *uint          `json:"attendance_record_id" gorm:"column:attendance_record_id;uniqueIndex"`
+	Date               time.Time      `json:"date" gorm:"type:date;not null"`
+	Minutes            int            `json:"minutes" gorm:"not null;check:minutes > 0"`
+	DayType            DayType        `json:"day_type" gorm:"column:day_type;size:20;not null"`
+	Multiplier         float64        `json:"multiplier" gorm:"type:decimal(4,2);not null"`
+	Reason             *string        `json:"reason" gorm:"size:500"`
+	Status             OvertimeStatus `json:"status" gorm:"size:20;default:'pending';not null"`
+	ApproverID         *uint          `json:"approver_id" gorm:"column:approver_id"`
+	ReviewedAt         *time.Time     `json:"reviewed_at" gorm:"column:reviewed_at"`
+	ReviewNote         *string        `json:"review_note" gorm:"column:review_note;size:500"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Employee *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Approver *Employee `json:"approver,omitempty" gorm:"foreignKey:ApproverID"`
+}
+
+// TableName specifies the table name for Holiday model
+func (Holiday) TableName() string {
+	return "holidays"
+}
+
+// TableName specifies the table name for OvertimeRequest model
+func (OvertimeRequest) TableName() string {
+	return "overtime_requests"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RateLimitBucket is a token bucket of the Postgres rate limit store
+type RateLimitBucket struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:200"`
+	Tokens    float64   `json:"tokens" gorm:"not null"`
+	Allowed   bool      `json:"allowed" gorm:"not null"` // whether the last take succeeded
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;index"`
+}
+
+// TableName specifies the table name for RateLimitBucket model
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CodeSequence is the counter behind generated codes such as EmployeeID and
+// StudentCode, one row per pattern scope
+type CodeSequence struct {
+	Scope     string    `json:"scope" gorm:"primaryKey;size:100"`
+	Value     int64     `json:"value" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for CodeSequence model
+func (CodeSequence) TableName() string {
+	return "code_sequences"
+}
@@ -5,6 +5,14 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	StudentStatusActive    = "active"
+	StudentStatusInactive  = "inactive"
+	StudentStatusSuspended = "suspended"
+	StudentStatusGraduated = "graduated"
+	StudentStatusWithdrawn = "withdrawn"
+)
+
 type Student struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	StudentCode string         `json:"student_code" gorm:"unique;not null;size:20"`
@@ -16,8 +24,10 @@ type Student struct {
 	Address     *string        `json:"address" gorm:"size:500"`
 	Major       *string        `json:"major" gorm:"size:100"`
 	Year        *int           `json:"year" gorm:"check:year >= 1 AND year <= 6"`
-	GPA         *float64       `json:"gpa" gorm:"type:decimal(3,2);check:gpa >= 0 AND gpa <= 4"`
-	Status      string         `json:"status" gorm:"default:'active';size:20;check:status IN ('active', 'inactive', 'graduated', 'suspended')"`
+	GPA         *float64       `json:"gpa" gorm:"type:decimal(3,2);check:gpa >= 0 AND gpa <= 4"` // computed from grades
+	Status      string         `json:"status" gorm:"default:'active';size:20;check:status IN ('active', 'inactive', 'graduated', 'suspended', 'withdrawn')"`
+	Flagged     bool           `json:"flagged" gorm:"not null;default:false"`
+	FlagReason  *string        `json:"flag_reason" gorm:"column:flag_reason;size:255"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -27,3 +37,35 @@ type Student struct {
 func (Student) TableName() string {
 	return "students"
 }
+
+// StudentStatusHistory records every status transition of a student
+type StudentStatusHistory struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	StudentID     uint      `json:"student_id" gorm:"column:student_id;not null;index"`
+	FromStatus    string    `json:"from_status" gorm:"column:from_status;size:20"`
+	ToStatus      string    `json:"to_status" gorm:"column:to_status;size:20;not null"`
+	Reason        *string   `json:"reason" gorm:"size:500"`
+	EffectiveDate time.Time `json:"effective_date" gorm:"column:effective_date;type:date;not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for StudentStatusHistory model
+func (StudentStatusHistory) TableName() string {
+	return "student_status_history"
+}
+
+// StudentRollover records a completed year-end rollover so it runs once per
+// academic year
+type StudentRollover struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	AcademicYear string    `json:"academic_year" gorm:"column:academic_year;size:20;unique;not null"`
+	Promoted     int       `json:"promoted" gorm:"not null"`
+	Graduated    int       `json:"graduated" gorm:"not null"`
+	Flagged      int       `json:"flagged" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for StudentRollover model
+func (StudentRollover) TableName() string {
+	return "student_rollovers"
+}
@@ -5,11 +5,73 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	UserRoleAdmin    = "admin"
+	UserRoleHR       = "hr"
+	UserRoleEmployee = "employee"
+	UserRoleStudent  = "student"
+)
+
+// User is a login account. It links to at most one Student or Employee record,
+// which the user can then reach through the /me endpoints.
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"not null"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Name              string         `json:"name" gorm:"not null"`
+	Email             string         `json:"email" gorm:"unique;not null"`
+	PasswordHash      *string        `json:"-" gorm:"column:password_hash;size:100"`
+	PasswordChangedAt *time.Time     `json:"password_changed_at" gorm:"column:password_changed_at"` // revokes older access tokens
+	FailedLogins      int            `json:"-" gorm:"column:failed_logins;not null;default:0"`
+	LockedUntil       *time.Time     `json:"locked_until" gorm:"column:locked_until"`
+	TOTPSecret        *string        `json:"-" gorm:"column:totp_secret;size:64"`
+	TOTPEnabled       bool           `json:"totp_enabled" gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastCounter   int64          `json:"-" gorm:"column:totp_last_counter;not null;default:0"` // last time step used, against replays
+	Role              string         `json:"role" gorm:"size:20;default:'employee';not null;check:role IN ('admin', 'hr', 'employee', 'student')"`
+	StudentID         *uint          `json:"student_id" gorm:"column:student_id;uniqueIndex"`
+	EmployeeID        *uint          `json:"employee_id" gorm:"column:employee_id;uniqueIndex"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Relationships
+	Student  *Student  `json:"student,omitempty" gorm:"foreignKey:StudentID"`
+	Employee *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+}
+
+const (
+	UserTokenInvite        = "invite"
+	UserTokenPasswordReset = "password_reset"
+)
+
+// UserToken is a single-use token sent to a user by email. Only the SHA-256 of
+// the token is stored.
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"column:user_id;not null;index"`
+	Purpose   string     `json:"purpose" gorm:"size:20;not null"`
+	TokenHash string     `json:"-" gorm:"column:token_hash;size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name for UserToken model
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// UserRecoveryCode is a one-time 2FA recovery code. Only its SHA-256 is stored.
+type UserRecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"column:user_id;not null;index"`
+	CodeHash  string     `json:"-" gorm:"column:code_hash;size:64;not null"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for UserRecoveryCode model
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
 }
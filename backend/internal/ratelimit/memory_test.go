@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a MemoryStore whose clock only moves when advance is called
+func newTestStore() (*MemoryStore, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func take(t *testing.T, s *MemoryStore, key string, policy Policy) Result {
+	t.Helper()
+	r, err := s.Take(context.Background(), key, policy)
+	if err != nil {
+		t.Fatalf("Take(%q): %v", key, err)
+	}
+	return r
+}
+
+func TestMemoryStoreDeniesWhenEmpty(t *testing.T) {
+	s, _ := newTestStore()
+	policy := Policy{Name: "test", Limit: 3, Window: time.Minute}
+
+	for want := 2; want >= 0; want-- {
+		r := take(t, s, "client", policy)
+		if !r.Allowed || r.Remaining != want {
+			t.Fatalf("Take = allowed %v, remaining %d; want allowed, remaining %d", r.Allowed, r.Remaining, want)
+		}
+	}
+
+	r := take(t, s, "client", policy)
+	if r.Allowed {
+		t.Fatal("Take allowed a request from an empty bucket")
+	}
+	if r.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", r.Remaining)
+	}
+	// One token refills every 20s at 3 per minute
+	if r.RetryAfter != 20*time.Second {
+		t.Errorf("RetryAfter = %v, want 20s", r.RetryAfter)
+	}
+	if r.Reset != time.Minute {
+		t.Errorf("Reset = %v, want 1m", r.Reset)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	s, advance := newTestStore()
+	policy := Policy{Name: "test", Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		take(t, s, "client", policy)
+	}
+
+	advance(19 * time.Second)
+	if r := take(t, s, "client", policy); r.Allowed {
+		t.Fatal("Take allowed a request before a token refilled")
+	}
+
+	advance(time.Second)
+	if r := take(t, s, "client", policy); !r.Allowed {
+		t.Fatal("Take denied a request after a token refilled")
+	}
+	if r := take(t, s, "client", policy); r.Allowed {
+		t.Fatal("Take allowed a second request from one refilled token")
+	}
+
+	// A long idle period refills the bucket up to the limit, not beyond
+	advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if r := take(t, s, "client", policy); !r.Allowed {
+			t.Fatalf("request %d denied after the bucket refilled", i+1)
+		}
+	}
+	if r := take(t, s, "client", policy); r.Allowed {
+		t.Fatal("bucket refilled beyond its limit")
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	policy := Policy{Name: "test", Limit: 1, Window: time.Minute}
+
+	if r := take(t, s, "a", policy); !r.Allowed {
+		t.Fatal("first request for a denied")
+	}
+	if r := take(t, s, "a", policy); r.Allowed {
+		t.Fatal("second request for a allowed")
+	}
+	if r := take(t, s, "b", policy); !r.Allowed {
+		t.Fatal("request for b denied by a's bucket")
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	s, advance := newTestStore()
+	policy := Policy{Name: "test", Limit: 5, Window: time.Minute}
+
+	take(t, s, "idle", policy)
+	advance(2 * time.Minute)
+	take(t, s, "active", policy)
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("active bucket is missing")
+	}
+}
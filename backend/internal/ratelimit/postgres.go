@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// pruneEvery is how many takes pass between deletions of stale buckets
+const pruneEvery = 1000
+
+// refill is the bucket level after refilling for the time since its last use.
+// It uses the database clock so all replicas agree.
+const refill = `LEAST(CAST(@limit AS double precision),
+	b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * CAST(@rate AS double precision))`
+
+// takeQuery refills and takes from a bucket in one atomic upsert
+const takeQuery = `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@limit AS double precision) - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN ` + refill + ` >= 1 THEN ` + refill + ` - 1 ELSE ` + refill + ` END,
+	allowed = ` + refill + ` >= 1,
+	updated_at = now()
+RETURNING tokens, allowed`
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// replica shares the same limits
+type PostgresStore struct {
+	db    *gorm.DB
+	takes atomic.Int64
+}
+
+// NewPostgresStore returns a store backed by db
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+	err := s.db.WithContext(ctx).Raw(takeQuery, map[string]any{
+		"key":   key,
+		"limit": policy.Limit,
+		"rate":  policy.rate(),
+	}).Scan(&row).Error
+	if err != nil {
+		return Result{}, err
+	}
+
+	if s.takes.Add(1)%pruneEvery == 0 {
+		s.db.WithContext(ctx).Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < now() - interval '1 day'`)
+	}
+	return policy.result(row.Tokens, row.Allowed), nil
+}
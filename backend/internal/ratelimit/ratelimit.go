@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Window as a token bucket: the bucket holds
+// up to Limit tokens and refills continuously at Limit per Window, so short
+// bursts are allowed as long as the average rate stays under the limit.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// ParsePolicy parses a policy written as "<limit>/<window>", e.g. "10/1m"
+func ParsePolicy(name, spec string) (Policy, error) {
+	limit, window, ok := strings.Cut(strings.TrimSpace(spec), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %s: %q is not <limit>/<window>", name, spec)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid limit %q", name, limit)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid window %q", name, window)
+	}
+	return Policy{Name: name, Limit: n, Window: d}, nil
+}
+
+// rate is the refill rate in tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// result turns the tokens left in a bucket into a Result
+func (p Policy) result(tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(p.Limit) - tokens) / p.rate() * float64(time.Second)),
+	}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) / p.rate() * float64(time.Second))
+	}
+	return r
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Take removes one token from the bucket for key
+// under policy if one is available.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lower-cases s and strips diacritics the same way the database-side
+// f_unaccent(lower(...)) does, so "Nguyễn Văn Đức" becomes "nguyen van duc"
+func Fold(s string) string {
+	folded, _ := fold(s)
+	return string(folded)
+}
+
+// Tokens splits a query into folded, non-empty search terms
+func Tokens(q string) []string {
+	return strings.FieldsFunc(Fold(q), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+}
+
+// Highlight wraps every occurrence of the tokens in text with <mark> tags,
+// matching on folded text but marking the original characters. The rest of
+// the text is HTML-escaped. With prefix set, tokens only match at the start
+// of a word. The second result reports whether anything matched.
+func Highlight(text string, tokens []string, prefix bool) (string, bool) {
+	original := []rune(text)
+	folded, origin := fold(text)
+
+	marked := make([]bool, len(original))
+	matched := false
+	for _, token := range tokens {
+		needle := []rune(token)
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(folded); i++ {
+			if prefix && i > 0 && isWordRune(folded[i-1]) {
+				continue
+			}
+			if !hasPrefix(folded[i:], needle) {
+				continue
+			}
+			matched = true
+			for j := origin[i]; j <= origin[i+len(needle)-1]; j++ {
+				marked[j] = true
+			}
+		}
+	}
+	if !matched {
+		return html.EscapeString(text), false
+	}
+
+	var b strings.Builder
+	open := false
+	for i, r := range original {
+		if marked[i] != open {
+			if open {
+				b.WriteString("</mark>")
+			} else {
+				b.WriteString("<mark>")
+			}
+			open = marked[i]
+		}
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+	return b.String(), true
+}
+
+// fold returns the folded runes of s along with, for each of them, the index
+// of the original rune it came from
+func fold(s string) ([]rune, []int) {
+	var folded []rune
+	var origin []int
+	for i, r := range []rune(s) {
+		for _, d := range norm.NFD.String(string(unicode.ToLower(r))) {
+			if unicode.Is(unicode.Mn, d) {
+				continue
+			}
+			if d == 'đ' {
+				d = 'd'
+			}
+			folded = append(folded, d)
+			origin = append(origin, i)
+		}
+	}
+	return folded, origin
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}